@@ -8,6 +8,7 @@ package audio
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"mime/multipart"
 	"net/http"
@@ -105,6 +106,21 @@ type Response struct {
 }
 
 func MakeTranscriptionRequest(request *TranscriptionRequest, organizationID *string) (*Response, error) {
+	return MakeTranscriptionRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeTranscriptionRequest, except the request is made with the given context.
+func MakeTranscriptionRequestContext(ctx context.Context, request *TranscriptionRequest, organizationID *string) (*Response, error) {
+	return MakeTranscriptionRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeTranscriptionRequest, except the request is sent using the given client.
+func MakeTranscriptionRequestWithClient(client *common.Client, request *TranscriptionRequest, organizationID *string) (*Response, error) {
+	return MakeTranscriptionRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeTranscriptionRequestWithClient, except the request is made with the given context.
+func MakeTranscriptionRequestWithClientContext(ctx context.Context, client *common.Client, request *TranscriptionRequest, organizationID *string) (*Response, error) {
 	buf := new(bytes.Buffer)
 	writer := multipart.NewWriter(buf)
 	err := common.CreateFormField("model", request.Model, writer)
@@ -112,12 +128,12 @@ func MakeTranscriptionRequest(request *TranscriptionRequest, organizationID *str
 		return nil, err
 	}
 
-	err = common.CreateFormFile("file", filepath.Base(request.File), request.File, writer)
+	err = common.CreateFormFileWithClientContext(ctx, client, "file", filepath.Base(request.File), request.File, writer)
 	if err != nil {
 		return nil, err
 	}
 	writer.Close()
-	r, err := common.MakeRequestWithForm[Response](buf, TransciptionEndpoint, http.MethodPost, writer.FormDataContentType(), organizationID)
+	r, err := common.MakeRequestWithFormAndClientContext[Response](ctx, client, buf, TransciptionEndpoint, http.MethodPost, writer.FormDataContentType(), organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -131,6 +147,21 @@ func MakeTranscriptionRequest(request *TranscriptionRequest, organizationID *str
 }
 
 func MakeTranslationRequest(request *TranslationRequest, organizationID *string) (*Response, error) {
+	return MakeTranslationRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeTranslationRequest, except the request is made with the given context.
+func MakeTranslationRequestContext(ctx context.Context, request *TranslationRequest, organizationID *string) (*Response, error) {
+	return MakeTranslationRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeTranslationRequest, except the request is sent using the given client.
+func MakeTranslationRequestWithClient(client *common.Client, request *TranslationRequest, organizationID *string) (*Response, error) {
+	return MakeTranslationRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeTranslationRequestWithClient, except the request is made with the given context.
+func MakeTranslationRequestWithClientContext(ctx context.Context, client *common.Client, request *TranslationRequest, organizationID *string) (*Response, error) {
 	buf := new(bytes.Buffer)
 	writer := multipart.NewWriter(buf)
 	err := common.CreateFormField("model", request.Model, writer)
@@ -138,12 +169,12 @@ func MakeTranslationRequest(request *TranslationRequest, organizationID *string)
 		return nil, err
 	}
 
-	err = common.CreateFormFile("file", filepath.Base(request.File), request.File, writer)
+	err = common.CreateFormFileWithClientContext(ctx, client, "file", filepath.Base(request.File), request.File, writer)
 	if err != nil {
 		return nil, err
 	}
 	writer.Close()
-	r, err := common.MakeRequestWithForm[Response](buf, TranslationEndpoint, http.MethodPost, writer.FormDataContentType(), organizationID)
+	r, err := common.MakeRequestWithFormAndClientContext[Response](ctx, client, buf, TranslationEndpoint, http.MethodPost, writer.FormDataContentType(), organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -189,7 +220,22 @@ type SpeechRequest struct {
 }
 
 func MakeSpeechRequest(request *SpeechRequest, organizationID *string) ([]byte, error) {
-	r, err := common.MakeRequest[SpeechRequest, []byte](request, SpeechEndpoint, http.MethodPost, organizationID)
+	return MakeSpeechRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeSpeechRequest, except the request is made with the given context.
+func MakeSpeechRequestContext(ctx context.Context, request *SpeechRequest, organizationID *string) ([]byte, error) {
+	return MakeSpeechRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeSpeechRequest, except the request is sent using the given client.
+func MakeSpeechRequestWithClient(client *common.Client, request *SpeechRequest, organizationID *string) ([]byte, error) {
+	return MakeSpeechRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeSpeechRequestWithClient, except the request is made with the given context.
+func MakeSpeechRequestWithClientContext(ctx context.Context, client *common.Client, request *SpeechRequest, organizationID *string) ([]byte, error) {
+	r, err := common.MakeRequestWithClientContext[SpeechRequest, []byte](ctx, client, request, SpeechEndpoint, http.MethodPost, organizationID)
 	if err != nil {
 		return nil, err
 	}
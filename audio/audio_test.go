@@ -6,8 +6,8 @@ import (
 	"testing"
 
 	"github.com/Kardbord/gopenai/audio"
-	"github.com/Kardbord/gopenai/authentication"
 	"github.com/Kardbord/gopenai/common"
+	"github.com/Kardbord/gopenai/openaitest"
 )
 
 const (
@@ -17,9 +17,8 @@ const (
 	model                 = "whisper-1"
 )
 
-func init() {
-	key := os.Getenv(OpenAITokenEnv)
-	authentication.SetAPIKey(key)
+func TestMain(m *testing.M) {
+	os.Exit(openaitest.Main(m))
 }
 
 func TestTranscription(t *testing.T) {
@@ -11,6 +11,7 @@ const (
 	AuthHeaderKey    = "Authorization"
 	AuthHeaderPrefix = "Bearer "
 	OrgHeaderKey     = "OpenAI-Organization"
+	ProjectHeaderKey = "OpenAI-Project"
 )
 
 var (
@@ -62,3 +63,30 @@ func DefaultOrganizationID() string {
 	defer orgMutex.RUnlock()
 	return defaultOrgID
 }
+
+var (
+	defaultProjectID = ""
+	projectMutex     = sync.RWMutex{}
+)
+
+// SetDefaultProjectID sets the project ID sent in the
+// ProjectHeaderKey header of every request by clients
+// which are not configured with their own.
+func SetDefaultProjectID(projectID string) error {
+	if len(projectID) == 0 {
+		return errors.New("provided ID was the empty string")
+	}
+	projectMutex.Lock()
+	defer projectMutex.Unlock()
+	defaultProjectID = projectID
+	return nil
+}
+
+// DefaultProjectID returns the default project ID
+// set by SetDefaultProjectID. It is not required for this
+// to be set.
+func DefaultProjectID() string {
+	projectMutex.RLock()
+	defer projectMutex.RUnlock()
+	return defaultProjectID
+}
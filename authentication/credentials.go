@@ -0,0 +1,302 @@
+package authentication
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The environment variables read by EnvProvider by default.
+const (
+	APIKeyEnvVar         = "OPENAI_API_KEY"
+	OrganizationIDEnvVar = "OPENAI_ORG_ID"
+	ProjectIDEnvVar      = "OPENAI_PROJECT_ID"
+)
+
+// How long a CommandProvider caches the key printed by its command,
+// unless configured otherwise.
+const DefaultCommandTTL = 5 * time.Minute
+
+// ErrNoCredentials is returned by a CredentialProvider which has no API key to provide.
+var ErrNoCredentials = errors.New("no API key provided")
+
+// The Credentials used to authenticate a single request.
+type Credentials struct {
+	APIKey string
+
+	// Optional. These are only used by requests for which neither the
+	// client nor the request function specifies an organization or project.
+	OrganizationID string
+	ProjectID      string
+
+	release func()
+}
+
+// Release reports that the request authenticated with the credentials has
+// completed. It is called by the client, and is only needed by providers
+// which balance requests across keys, such as LeastUsed.
+func (c Credentials) Release() {
+	if c.release != nil {
+		c.release()
+	}
+}
+
+// A CredentialProvider provides the Credentials of each request, such
+// as to read an API key which is rotated, or to spread requests across
+// several keys. Credentials is called once per attempt of every request,
+// so it should be fast and safe for concurrent use.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// A CredentialProviderFunc is a function which implements CredentialProvider.
+type CredentialProviderFunc func(ctx context.Context) (Credentials, error)
+
+func (f CredentialProviderFunc) Credentials(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+var (
+	defaultProvider      CredentialProvider
+	defaultProviderMutex = sync.RWMutex{}
+)
+
+// SetDefaultCredentialProvider sets the CredentialProvider used by clients
+// which are not configured with their own, in place of the API key set with
+// SetAPIKey. Passing nil restores the use of SetAPIKey.
+func SetDefaultCredentialProvider(provider CredentialProvider) {
+	defaultProviderMutex.Lock()
+	defer defaultProviderMutex.Unlock()
+	defaultProvider = provider
+}
+
+// DefaultCredentialProvider returns the CredentialProvider set by
+// SetDefaultCredentialProvider, or nil if none is set.
+func DefaultCredentialProvider() CredentialProvider {
+	defaultProviderMutex.RLock()
+	defer defaultProviderMutex.RUnlock()
+	return defaultProvider
+}
+
+// Static returns a CredentialProvider which always provides creds.
+func Static(creds Credentials) CredentialProvider {
+	return CredentialProviderFunc(func(ctx context.Context) (Credentials, error) {
+		if len(creds.APIKey) == 0 {
+			return Credentials{}, ErrNoCredentials
+		}
+		return creds, nil
+	})
+}
+
+// StaticKey returns a CredentialProvider which always provides the given API key.
+func StaticKey(key string) CredentialProvider {
+	return Static(Credentials{APIKey: key})
+}
+
+// An EnvProvider provides credentials read from environment variables
+// on every request. Unset variable names default to APIKeyEnvVar,
+// OrganizationIDEnvVar and ProjectIDEnvVar.
+type EnvProvider struct {
+	APIKeyVar         string
+	OrganizationIDVar string
+	ProjectIDVar      string
+}
+
+func (p EnvProvider) Credentials(ctx context.Context) (Credentials, error) {
+	creds := Credentials{
+		APIKey:         os.Getenv(envVar(p.APIKeyVar, APIKeyEnvVar)),
+		OrganizationID: os.Getenv(envVar(p.OrganizationIDVar, OrganizationIDEnvVar)),
+		ProjectID:      os.Getenv(envVar(p.ProjectIDVar, ProjectIDEnvVar)),
+	}
+	if len(creds.APIKey) == 0 {
+		return Credentials{}, fmt.Errorf("%w: %s is not set", ErrNoCredentials, envVar(p.APIKeyVar, APIKeyEnvVar))
+	}
+	return creds, nil
+}
+
+func envVar(name, fallback string) string {
+	if len(name) != 0 {
+		return name
+	}
+	return fallback
+}
+
+// A FileProvider provides the API key stored in a file, such as a mounted
+// secret. The file is read again whenever it changes, so that a rotated key
+// is used without restarting the process.
+type FileProvider struct {
+	path string
+
+	mu      sync.Mutex
+	key     string
+	modTime time.Time
+	size    int64
+}
+
+// NewFileProvider creates a FileProvider which reads the API key stored
+// in the file at path. Leading and trailing whitespace is ignored.
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{path: path}
+}
+
+func (p *FileProvider) Credentials(ctx context.Context) (Credentials, error) {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.key) == 0 || !info.ModTime().Equal(p.modTime) || info.Size() != p.size {
+		data, err := os.ReadFile(p.path)
+		if err != nil {
+			return Credentials{}, err
+		}
+		p.key = strings.TrimSpace(string(data))
+		p.modTime = info.ModTime()
+		p.size = info.Size()
+	}
+	if len(p.key) == 0 {
+		return Credentials{}, fmt.Errorf("%w: %s is empty", ErrNoCredentials, p.path)
+	}
+	return Credentials{APIKey: p.key}, nil
+}
+
+// A CommandProvider provides the API key printed by a command, such as a
+// secret manager's CLI. The key is cached for a configurable duration,
+// after which the command is run again.
+type CommandProvider struct {
+	name string
+	args []string
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	key     string
+	expires time.Time
+}
+
+// NewCommandProvider creates a CommandProvider which runs the named
+// program with the given arguments, caching the key it prints to
+// standard output for ttl. If ttl is not positive, DefaultCommandTTL is used.
+func NewCommandProvider(ttl time.Duration, name string, args ...string) *CommandProvider {
+	if ttl <= 0 {
+		ttl = DefaultCommandTTL
+	}
+	return &CommandProvider{name: name, args: args, ttl: ttl}
+}
+
+func (p *CommandProvider) Credentials(ctx context.Context) (Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.key) != 0 && time.Now().Before(p.expires) {
+		return Credentials{APIKey: p.key}, nil
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, p.name, p.args...)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return Credentials{}, fmt.Errorf("running %s: %w: %s", p.name, err, strings.TrimSpace(stderr.String()))
+	}
+	key := strings.TrimSpace(string(out))
+	if len(key) == 0 {
+		return Credentials{}, fmt.Errorf("%w: %s printed nothing", ErrNoCredentials, p.name)
+	}
+	p.key = key
+	p.expires = time.Now().Add(p.ttl)
+	return Credentials{APIKey: key}, nil
+}
+
+// Chain returns a CredentialProvider which provides the credentials of
+// the first of providers which succeeds, such as to prefer an environment
+// variable over a file.
+func Chain(providers ...CredentialProvider) CredentialProvider {
+	return CredentialProviderFunc(func(ctx context.Context) (Credentials, error) {
+		errs := []error{ErrNoCredentials}
+		for _, p := range providers {
+			creds, err := p.Credentials(ctx)
+			if err == nil {
+				return creds, nil
+			}
+			errs = append(errs, err)
+		}
+		return Credentials{}, errors.Join(errs...)
+	})
+}
+
+// RoundRobin returns a CredentialProvider which spreads requests evenly
+// across providers, such as several API keys, by using each in turn.
+func RoundRobin(providers ...CredentialProvider) CredentialProvider {
+	var (
+		mu   sync.Mutex
+		next int
+	)
+	return CredentialProviderFunc(func(ctx context.Context) (Credentials, error) {
+		if len(providers) == 0 {
+			return Credentials{}, ErrNoCredentials
+		}
+		mu.Lock()
+		p := providers[next]
+		next = (next + 1) % len(providers)
+		mu.Unlock()
+		return p.Credentials(ctx)
+	})
+}
+
+// LeastUsed returns a CredentialProvider which spreads requests across
+// providers, such as several API keys, by using the one with the fewest
+// requests in flight. Unlike RoundRobin, this accounts for requests which
+// take longer than others, such as long completions or streams.
+func LeastUsed(providers ...CredentialProvider) CredentialProvider {
+	var (
+		mu       sync.Mutex
+		inFlight = make([]int, len(providers))
+		next     int
+	)
+	return CredentialProviderFunc(func(ctx context.Context) (Credentials, error) {
+		if len(providers) == 0 {
+			return Credentials{}, ErrNoCredentials
+		}
+		mu.Lock()
+		// Ties are broken in turn, starting after the last provider used.
+		least := next
+		for i := 1; i < len(providers); i++ {
+			j := (next + i) % len(providers)
+			if inFlight[j] < inFlight[least] {
+				least = j
+			}
+		}
+		inFlight[least]++
+		next = (least + 1) % len(providers)
+		mu.Unlock()
+
+		var once sync.Once
+		release := func() {
+			once.Do(func() {
+				mu.Lock()
+				inFlight[least]--
+				mu.Unlock()
+			})
+		}
+		creds, err := providers[least].Credentials(ctx)
+		if err != nil {
+			release()
+			return Credentials{}, err
+		}
+		inner := creds.release
+		creds.release = func() {
+			release()
+			if inner != nil {
+				inner()
+			}
+		}
+		return creds, nil
+	})
+}
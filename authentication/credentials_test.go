@@ -0,0 +1,124 @@
+package authentication_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/Kardbord/gopenai/authentication"
+)
+
+func key(t *testing.T, provider authentication.CredentialProvider) string {
+	t.Helper()
+	creds, err := provider.Credentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return creds.APIKey
+}
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("TEST_OPENAI_KEY", "env-key")
+	t.Setenv(authentication.ProjectIDEnvVar, "project")
+	creds, err := authentication.EnvProvider{APIKeyVar: "TEST_OPENAI_KEY"}.Credentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.APIKey != "env-key" || creds.ProjectID != "project" {
+		t.Fatalf("unexpected credentials: %+v", creds)
+	}
+
+	_, err = authentication.EnvProvider{APIKeyVar: "TEST_OPENAI_MISSING"}.Credentials(context.Background())
+	if !errors.Is(err, authentication.ErrNoCredentials) {
+		t.Fatalf("expected ErrNoCredentials, got %v", err)
+	}
+}
+
+func TestFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("first-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	provider := authentication.NewFileProvider(path)
+	if k := key(t, provider); k != "first-key" {
+		t.Fatalf("unexpected key: %q", k)
+	}
+
+	if err := os.WriteFile(path, []byte("rotated-key\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if k := key(t, provider); k != "rotated-key" {
+		t.Fatalf("expected the rotated key, got %q", k)
+	}
+}
+
+func TestCommandProvider(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires echo")
+	}
+	provider := authentication.NewCommandProvider(time.Minute, "echo", "command-key")
+	if k := key(t, provider); k != "command-key" {
+		t.Fatalf("unexpected key: %q", k)
+	}
+
+	_, err := authentication.NewCommandProvider(0, "true").Credentials(context.Background())
+	if !errors.Is(err, authentication.ErrNoCredentials) {
+		t.Fatalf("expected ErrNoCredentials, got %v", err)
+	}
+}
+
+func TestChain(t *testing.T) {
+	chain := authentication.Chain(
+		authentication.EnvProvider{APIKeyVar: "TEST_OPENAI_MISSING"},
+		authentication.StaticKey("fallback"),
+	)
+	if k := key(t, chain); k != "fallback" {
+		t.Fatalf("unexpected key: %q", k)
+	}
+
+	_, err := authentication.Chain(authentication.StaticKey("")).Credentials(context.Background())
+	if !errors.Is(err, authentication.ErrNoCredentials) {
+		t.Fatalf("expected ErrNoCredentials, got %v", err)
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	provider := authentication.RoundRobin(authentication.StaticKey("a"), authentication.StaticKey("b"))
+	keys := ""
+	for i := 0; i < 4; i++ {
+		keys += key(t, provider)
+	}
+	if keys != "abab" {
+		t.Fatalf("unexpected keys: %s", keys)
+	}
+}
+
+func TestLeastUsed(t *testing.T) {
+	provider := authentication.LeastUsed(authentication.StaticKey("a"), authentication.StaticKey("b"), authentication.StaticKey("c"))
+	acquire := func() authentication.Credentials {
+		creds, err := provider.Credentials(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return creds
+	}
+
+	a, b, c := acquire(), acquire(), acquire()
+	if a.APIKey != "a" || b.APIKey != "b" || c.APIKey != "c" {
+		t.Fatalf("unexpected keys: %s, %s, %s", a.APIKey, b.APIKey, c.APIKey)
+	}
+	b.Release()
+	b.Release()
+	if next := acquire(); next.APIKey != "b" {
+		t.Fatalf("expected the released key, got %q", next.APIKey)
+	}
+	a.Release()
+	c.Release()
+	if next := acquire(); next.APIKey != "c" {
+		t.Fatalf("expected ties to be broken in turn, got %q", next.APIKey)
+	}
+}
@@ -0,0 +1,234 @@
+// Package azure sends requests to the [Azure OpenAI service], rather than
+// to the OpenAI API.
+//
+//	client := azure.NewClient(azure.Config{
+//		Endpoint:    "https://my-resource.openai.azure.com",
+//		APIKey:      os.Getenv("AZURE_OPENAI_API_KEY"),
+//		Deployments: map[string]string{"gpt-4o": "my-gpt-4o-deployment"},
+//	})
+//	resp, err := client.MakeChatRequest(&chat.Request{Model: "gpt-4o", ...}, nil)
+//
+// Requests to endpoints served by a deployment, such as chat completions
+// and embeddings, are sent to the deployment of the request's model.
+// Other requests, such as for files and fine-tuning jobs, are sent to the
+// resource itself. Every request includes the configured API version.
+//
+// Azure's content filter results are available on chat responses, as
+// chat.Response.PromptFilterResults and chat.Choice.ContentFilterResults.
+//
+// [Azure OpenAI service]: https://learn.microsoft.com/azure/ai-services/openai/
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Kardbord/gopenai"
+	auth "github.com/Kardbord/gopenai/authentication"
+	"github.com/Kardbord/gopenai/common"
+)
+
+const (
+	// The API version used when Config.APIVersion is not set.
+	DefaultAPIVersion = "2024-10-21"
+
+	// The header used to authenticate requests with an API key.
+	APIKeyHeaderKey = "api-key"
+
+	// The query parameter which selects the API version.
+	APIVersionQueryKey = "api-version"
+
+	// The scope of the Microsoft Entra ID tokens accepted by the service.
+	TokenScope = "https://cognitiveservices.azure.com/.default"
+)
+
+// The operations served by a deployment, rather than by the resource.
+var deploymentOperations = map[string]bool{
+	"chat/completions":     true,
+	"completions":          true,
+	"embeddings":           true,
+	"audio/transcriptions": true,
+	"audio/translations":   true,
+	"audio/speech":         true,
+	"images/generations":   true,
+}
+
+// A TokenProvider provides Microsoft Entra ID access tokens for the
+// TokenScope, such as an azidentity credential wrapped in a
+// TokenProviderFunc. Tokens are requested for every request, so a
+// TokenProvider should cache them until they expire.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// A TokenProviderFunc is a function which implements TokenProvider.
+type TokenProviderFunc func(ctx context.Context) (string, error)
+
+func (f TokenProviderFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// The configuration of an Azure OpenAI resource.
+type Config struct {
+	// The endpoint of the resource, such as
+	// "https://my-resource.openai.azure.com".
+	Endpoint string
+
+	// The API version of every request. Defaults to DefaultAPIVersion.
+	APIVersion string
+
+	// The API key of the resource. Ignored if TokenProvider is set.
+	APIKey string
+
+	// Provides Microsoft Entra ID tokens, which are sent as bearer
+	// tokens instead of the API key.
+	TokenProvider TokenProvider
+
+	// Maps model names to the names of their deployments. The model of a
+	// request without a deployment in this map is used as its deployment.
+	Deployments map[string]string
+}
+
+// Deployment returns the name of the deployment of the given model.
+func (c Config) Deployment(model string) string {
+	if deployment, ok := c.Deployments[model]; ok {
+		return deployment
+	}
+	return model
+}
+
+func (c Config) apiVersion() string {
+	if len(c.APIVersion) != 0 {
+		return c.APIVersion
+	}
+	return DefaultAPIVersion
+}
+
+// BaseURL returns the basis of the endpoints of the resource.
+func (c Config) BaseURL() string {
+	return strings.TrimSuffix(c.Endpoint, "/") + "/openai/"
+}
+
+// NewClient creates a client which sends requests to the Azure OpenAI
+// resource described by config, configured with the given options.
+func NewClient(config Config, opts ...common.ClientOption) *gopenai.Client {
+	return gopenai.NewClient(append([]common.ClientOption{WithConfig(config)}, opts...)...)
+}
+
+// WithConfig configures a client to send requests to the Azure OpenAI
+// resource described by config, by setting its base URL and adding the
+// Middleware.
+func WithConfig(config Config) common.ClientOption {
+	baseURL := common.WithBaseURL(config.BaseURL())
+	middleware := common.WithMiddleware(Middleware(config))
+	return func(c *common.Client) {
+		baseURL(c)
+		middleware(c)
+	}
+}
+
+// Middleware rewrites requests sent to the base URL of the resource
+// described by config, such as "{endpoint}/openai/chat/completions", to
+// the URL of the deployment of the request's model, adds the API version,
+// and replaces the OpenAI authentication headers with the resource's.
+func Middleware(config Config) common.Middleware {
+	return func(next common.RoundTripFunc) common.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req, err := rewrite(config, req)
+			if err != nil {
+				return nil, err
+			}
+			return next(req)
+		}
+	}
+}
+
+func rewrite(config Config, req *http.Request) (*http.Request, error) {
+	base, err := url.Parse(config.BaseURL())
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.Host != base.Host || !strings.HasPrefix(req.URL.Path, base.Path) {
+		return req, nil
+	}
+
+	// Each attempt of a retried request passes through the middleware,
+	// so the original request must not be modified.
+	req = req.Clone(req.Context())
+	operation := strings.TrimPrefix(req.URL.Path, base.Path)
+	if deploymentOperations[operation] {
+		model, err := requestModel(req)
+		if err != nil {
+			return nil, err
+		}
+		if len(model) == 0 {
+			return nil, errors.New("azure: a model is required to select a deployment")
+		}
+		req.URL.Path = base.Path + "deployments/" + url.PathEscape(config.Deployment(model)) + "/" + operation
+		req.URL.RawPath = ""
+	}
+	query := req.URL.Query()
+	query.Set(APIVersionQueryKey, config.apiVersion())
+	req.URL.RawQuery = query.Encode()
+
+	req.Header.Del(auth.AuthHeaderKey)
+	req.Header.Del(auth.OrgHeaderKey)
+	req.Header.Del(auth.ProjectHeaderKey)
+	if config.TokenProvider != nil {
+		token, err := config.TokenProvider.Token(req.Context())
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(auth.AuthHeaderKey, auth.AuthHeaderPrefix+token)
+	} else {
+		req.Header.Set(APIKeyHeaderKey, config.APIKey)
+	}
+	return req, nil
+}
+
+// requestModel returns the model of a JSON or multipart request body.
+func requestModel(req *http.Request) (string, error) {
+	if req.GetBody == nil {
+		return "", nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		form := multipart.NewReader(bytes.NewReader(data), params["boundary"])
+		for {
+			part, err := form.NextPart()
+			if err != nil {
+				return "", nil
+			}
+			if part.FormName() == "model" {
+				model, err := io.ReadAll(part)
+				return string(model), err
+			}
+		}
+	}
+
+	var request struct {
+		Model string `json:"model"`
+	}
+	if err = json.Unmarshal(data, &request); err != nil {
+		return "", nil
+	}
+	return request.Model, nil
+}
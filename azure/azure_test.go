@@ -0,0 +1,142 @@
+package azure_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Kardbord/gopenai/audio"
+	"github.com/Kardbord/gopenai/azure"
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/common"
+	"github.com/Kardbord/gopenai/files"
+)
+
+const chatResponse = `{
+	"id": "chatcmpl-1",
+	"object": "chat.completion",
+	"model": "gpt-4o-2024-08-06",
+	"prompt_filter_results": [{
+		"prompt_index": 0,
+		"content_filter_results": {
+			"hate": {"filtered": false, "severity": "safe"},
+			"jailbreak": {"filtered": false, "detected": false}
+		}
+	}],
+	"choices": [{
+		"index": 0,
+		"finish_reason": "content_filter",
+		"message": {"role": "assistant", "content": null},
+		"content_filter_results": {
+			"violence": {"filtered": true, "severity": "medium"},
+			"protected_material_text": {"filtered": false, "detected": false}
+		}
+	}]
+}`
+
+func TestChat(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if r.URL.Path != "/openai/deployments/my-gpt-4o/chat/completions" || r.URL.Query().Get("api-version") != "2024-06-01" {
+			t.Errorf("unexpected URL: %s", r.URL)
+		}
+		if r.Header.Get("api-key") != "azure-key" || len(r.Header.Get("Authorization")) != 0 {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(chatResponse))
+	}))
+	defer server.Close()
+
+	client := azure.NewClient(azure.Config{
+		Endpoint:    server.URL,
+		APIVersion:  "2024-06-01",
+		APIKey:      "azure-key",
+		Deployments: map[string]string{"gpt-4o": "my-gpt-4o"},
+	},
+		common.WithAPIKey("openai-key"),
+		common.WithRetryPolicy(common.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+	)
+
+	resp, err := client.MakeChatRequest(&chat.Request{
+		Model:    "gpt-4o",
+		Messages: []chat.Chat{{Role: chat.UserRole, Content: "Hello"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts)
+	}
+	if len(resp.PromptFilterResults) != 1 || resp.PromptFilterResults[0].ContentFilterResults.Filtered() ||
+		resp.PromptFilterResults[0].ContentFilterResults.Hate.Severity != "safe" {
+		t.Fatalf("unexpected prompt filter results: %+v", resp.PromptFilterResults)
+	}
+	choice := resp.Choices[0]
+	if choice.FinishReason != chat.FinishReasonContentFilter || !choice.ContentFilterResults.Filtered() ||
+		choice.ContentFilterResults.Violence.Severity != "medium" {
+		t.Fatalf("unexpected content filter results: %+v", choice.ContentFilterResults)
+	}
+}
+
+func TestTokenProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.Header.Get("api-key")) != 0 || r.Header.Get("Authorization") != "Bearer entra-token" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		switch r.URL.Path {
+		case "/openai/deployments/whisper/audio/transcriptions":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"text":"hello"}`))
+		case "/openai/files":
+			if r.URL.Query().Get("api-version") != azure.DefaultAPIVersion {
+				t.Errorf("unexpected URL: %s", r.URL)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"object":"list","data":[]}`))
+		default:
+			t.Errorf("unexpected URL: %s", r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := azure.NewClient(azure.Config{
+		Endpoint: server.URL + "/",
+		TokenProvider: azure.TokenProviderFunc(func(ctx context.Context) (string, error) {
+			return "entra-token", nil
+		}),
+	})
+
+	audioFile := filepath.Join(t.TempDir(), "audio.mp3")
+	if err := os.WriteFile(audioFile, []byte("audio"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	transcription, err := audio.MakeTranscriptionRequestWithClient(client.Client, &audio.TranscriptionRequest{File: audioFile, Model: "whisper"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transcription.Text != "hello" {
+		t.Fatalf("unexpected transcription: %+v", transcription)
+	}
+
+	if _, err = files.MakeListRequestWithClient(client.Client, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeployment(t *testing.T) {
+	config := azure.Config{Deployments: map[string]string{"gpt-4o": "prod"}}
+	if config.Deployment("gpt-4o") != "prod" || config.Deployment("gpt-4o-mini") != "gpt-4o-mini" {
+		t.Fatal("unexpected deployments")
+	}
+}
@@ -7,10 +7,12 @@
 package chat
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
 	"github.com/Kardbord/gopenai/common"
+	"github.com/Kardbord/gopenai/jsonschema"
 	"github.com/Kardbord/gopenai/moderations"
 )
 
@@ -22,6 +24,7 @@ const (
 	SystemRole    Role = "system"
 	UserRole      Role = "user"
 	AssistantRole Role = "assistant"
+	ToolRole      Role = "tool"
 )
 
 type FunctionCall struct {
@@ -36,17 +39,83 @@ type ToolCall struct {
 }
 
 type Chat struct {
-	Content   string     `json:"content"`
+	Content string `json:"content"`
+
+	// The parts of a multimodal message, such as text and images.
+	// When set, ContentParts is sent instead of Content. See
+	// NewMultipartMessage.
+	ContentParts []ContentPart `json:"-"`
+
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 	Role      Role       `json:"role"`
 
+	// The ID of the tool call a ToolRole message is responding to.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+
+	// The refusal message generated by the model, when it declines
+	// to respond in the requested ResponseFormat.
+	Refusal string `json:"refusal,omitempty"`
+
+	// An optional name for the participant.
+	Name string `json:"name,omitempty"`
+
 	// Deprecated: Use ToolCalls instead
 	FunctionCall []FunctionCall `json:"function_call,omitempty"`
 }
 
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
 type ResponseFormat struct {
-	// Must be one of text or json_object.
+	// Must be one of text, json_object or json_schema.
 	Type string `json:"type,omitempty"`
+
+	// The schema the response must match. Only set this when
+	// Type is json_schema.
+	JSONSchema *JSONSchema `json:"json_schema,omitempty"`
+}
+
+// A JSON Schema which the model's response must match, for
+// the json_schema ResponseFormat.
+type JSONSchema struct {
+	// The name of the response format. Must be a-z, A-Z, 0-9, or
+	// contain underscores and dashes, with a maximum length of 64.
+	Name string `json:"name"`
+
+	// A description of what the response format is for, used by
+	// the model to determine how to respond in the format.
+	Description string `json:"description,omitempty"`
+
+	// The schema of the response, such as a *jsonschema.Schema
+	// or a json.RawMessage.
+	Schema any `json:"schema,omitempty"`
+
+	// Whether to enable strict schema adherence when generating the output.
+	// If set to true, the model will always follow the exact schema defined
+	// in the schema field. Only a subset of JSON Schema is supported when
+	// strict is true.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// NewJSONSchemaResponseFormat creates a strict json_schema ResponseFormat
+// requiring the model to respond with a JSON encoding of a T. The schema
+// is generated from T as described by the jsonschema package.
+func NewJSONSchemaResponseFormat[T any](name string) (*ResponseFormat, error) {
+	schema, err := jsonschema.Generate[T]()
+	if err != nil {
+		return nil, err
+	}
+	return &ResponseFormat{
+		Type: ResponseFormatJSONSchema,
+		JSONSchema: &JSONSchema{
+			Name:   name,
+			Schema: schema,
+			Strict: true,
+		},
+	}, nil
 }
 
 // Request structure for the chat API endpoint.
@@ -76,6 +145,16 @@ type Request struct {
 	// ban or exclusive selection of the relevant token.
 	LogitBias map[string]int64 `json:"logit_bias,omitempty"`
 
+	// Whether to return log probabilities of the output tokens or not. If true,
+	// returns the log probabilities of each output token returned in the
+	// content of message.
+	LogProbs bool `json:"logprobs,omitempty"`
+
+	// An integer between 0 and 20 specifying the number of most likely tokens
+	// to return at each token position, each with an associated log probability.
+	// LogProbs must be set to true if this parameter is used.
+	TopLogProbs *int64 `json:"top_logprobs,omitempty"`
+
 	// The maximum number of tokens to generate in the chat completion.
 	// The total length of input tokens and generated tokens is limited
 	// by the model's context length.
@@ -90,6 +169,10 @@ type Request struct {
 	PresencePenalty *float64 `json:"presence_penalty,omitempty"`
 
 	// An object specifying the format that the model must output.
+	// Setting to "json_schema" enables Structured Outputs, which ensures
+	// the model will match the supplied JSON schema. See
+	// NewJSONSchemaResponseFormat.
+	//
 	// Setting to "json_object" enables JSON mode, which guarantees
 	// the message the model generates is valid JSON.
 	//
@@ -116,7 +199,13 @@ type Request struct {
 	// will be sent as data-only server-sent events as they become available,
 	// with the stream terminated by a data: [DONE] message. See the OpenAI
 	// Cookbook for example code.
-	// Stream bool `json:"stream,omitempty"` TODO: Add streaming support
+	//
+	// This is set automatically by MakeStreamingRequest, and should not be
+	// set when using MakeRequest.
+	Stream bool `json:"stream,omitempty"`
+
+	// Options for streaming responses. Only set this when Stream is true.
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
 
 	// What sampling temperature to use, between 0 and 2. Higher values
 	// like 0.8 will make the output more random, while lower values like
@@ -131,32 +220,99 @@ type Request struct {
 	// We generally recommend altering this or temperature but not both.
 	TopP *float64 `json:"top_p,omitempty"`
 
-	// TODO: Support tools
-	// Tools []Tool `json:"tools,omitempty"`
-	// TODO: Support ToolChoice
-	// ToolChoice ToolChoice `json:"tool_choice,omitempty"`
+	// A list of tools the model may call. Currently, only functions are
+	// supported as a tool. Use this to provide a list of functions the model
+	// may generate JSON inputs for.
+	Tools []Tool `json:"tools,omitempty"`
+
+	// Controls which (if any) tool is called by the model. ToolChoiceNone
+	// means the model will not call any tool and instead generates a message.
+	// ToolChoiceAuto means the model can pick between generating a message or
+	// calling one or more tools. ToolChoiceRequired means the model must call
+	// one or more tools. Use ToolChoiceFunction to force the model to call a
+	// specific tool.
+	//
+	// None is the default when no tools are present. Auto is the default
+	// if tools are present.
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+
+	// Whether to enable parallel function calling during tool use.
+	// Defaults to true.
+	ParallelToolCalls *bool `json:"parallel_tool_calls,omitempty"`
 
 	// A unique identifier representing your end-user, which can help OpenAI to monitor and detect abuse.
 	User string `json:"user,omitempty"`
 }
 
-type Response struct {
-	ID      string `json:"id,omitempty"`
-	Choices []struct {
-		Index        int64  `json:"index,omitempty"`
-		Message      Chat   `json:"message,omitempty"`
-		FinishReason string `json:"finish_reason,omitempty"`
+// EstimateTokens roughly estimates the number of tokens the request will
+// consume, including the maximum number of tokens it may generate.
+// It implements common.TokenEstimator, for rate limiting.
+func (r *Request) EstimateTokens() int64 {
+	// Every message is wrapped in a few tokens of formatting,
+	// and every reply is primed with a few more.
+	var tokens int64 = 3
+	for _, m := range r.Messages {
+		tokens += 4 + common.EstimateTokens(m.Text())
+	}
+
+	n := int64(1)
+	if r.N != nil && *r.N > 1 {
+		n = *r.N
 	}
+	if r.MaxTokens != nil {
+		tokens += *r.MaxTokens * n
+	}
+	return tokens
+}
+
+type Choice struct {
+	Index        int64  `json:"index,omitempty"`
+	Message      Chat   `json:"message,omitempty"`
+	FinishReason string `json:"finish_reason,omitempty"`
+
+	// Log probability information for the choice, when
+	// Request.LogProbs is set.
+	LogProbs *LogProbs `json:"logprobs,omitempty"`
+
+	// The results of the content filters applied to the choice.
+	// Only set by the Azure OpenAI service.
+	ContentFilterResults *ContentFilterResults `json:"content_filter_results,omitempty"`
+}
+
+type Response struct {
+	ID                string                `json:"id,omitempty"`
+	Choices           []Choice              `json:"choices"`
 	Created           int64                 `json:"created,omitempty"`
 	Model             string                `json:"model,omitempty"`
 	SystemFingerprint string                `json:"system_fingerprint,omitempty"`
 	Object            string                `json:"object,omitempty"`
 	Usage             common.ResponseUsage  `json:"usage"`
 	Error             *common.ResponseError `json:"error,omitempty"`
+
+	// The results of the content filters applied to the prompts.
+	// Only set by the Azure OpenAI service.
+	PromptFilterResults []PromptFilterResult `json:"prompt_filter_results,omitempty"`
+
+	common.CacheMetadata
 }
 
 func MakeRequest(request *Request, organizationID *string) (*Response, error) {
-	r, err := common.MakeRequest[Request, Response](request, Endpoint, http.MethodPost, organizationID)
+	return MakeRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeRequest, except the request is made with the given context.
+func MakeRequestContext(ctx context.Context, request *Request, organizationID *string) (*Response, error) {
+	return MakeRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeRequest, except the request is sent using the given client.
+func MakeRequestWithClient(client *common.Client, request *Request, organizationID *string) (*Response, error) {
+	return MakeRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeRequestWithClient, except the request is made with the given context.
+func MakeRequestWithClientContext(ctx context.Context, client *common.Client, request *Request, organizationID *string) (*Response, error) {
+	r, err := common.MakeRequestWithClientContext[Request, Response](ctx, client, request, Endpoint, http.MethodPost, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -173,12 +329,27 @@ func MakeRequest(request *Request, organizationID *string) (*Response, error) {
 }
 
 func MakeModeratedRequest(request *Request, organizationID *string) (*Response, *moderations.Response, error) {
+	return MakeModeratedRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeModeratedRequest, except the requests are made with the given context.
+func MakeModeratedRequestContext(ctx context.Context, request *Request, organizationID *string) (*Response, *moderations.Response, error) {
+	return MakeModeratedRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeModeratedRequest, except the requests are sent using the given client.
+func MakeModeratedRequestWithClient(client *common.Client, request *Request, organizationID *string) (*Response, *moderations.Response, error) {
+	return MakeModeratedRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeModeratedRequestWithClient, except the requests are made with the given context.
+func MakeModeratedRequestWithClientContext(ctx context.Context, client *common.Client, request *Request, organizationID *string) (*Response, *moderations.Response, error) {
 	input := make([]string, len(request.Messages))
 	for i := range request.Messages {
-		input[i] = request.Messages[i].Content
+		input[i] = request.Messages[i].Text()
 	}
 
-	modr, err := moderations.MakeModeratedRequest(&moderations.Request{
+	modr, err := moderations.MakeModeratedRequestWithClientContext(ctx, client, &moderations.Request{
 		Input: input,
 		Model: moderations.ModelLatest,
 	}, organizationID)
@@ -186,6 +357,6 @@ func MakeModeratedRequest(request *Request, organizationID *string) (*Response,
 		return nil, modr, err
 	}
 
-	r, err := MakeRequest(request, organizationID)
+	r, err := MakeRequestWithClientContext(ctx, client, request, organizationID)
 	return r, modr, err
 }
@@ -4,15 +4,14 @@ import (
 	"os"
 	"testing"
 
-	"github.com/Kardbord/gopenai/authentication"
 	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/openaitest"
 )
 
 const OpenAITokenEnv = "OPENAI_API_KEY"
 
-func init() {
-	key := os.Getenv(OpenAITokenEnv)
-	authentication.SetAPIKey(key)
+func TestMain(m *testing.M) {
+	os.Exit(openaitest.MainWithCassette(m, "testdata/cassette.json"))
 }
 
 func TestChat(t *testing.T) {
@@ -0,0 +1,222 @@
+package chat
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Kardbord/gopenai/common"
+)
+
+// The types of ContentPart.
+const (
+	ContentPartText       = "text"
+	ContentPartImageURL   = "image_url"
+	ContentPartInputAudio = "input_audio"
+	ContentPartFile       = "file"
+)
+
+// The detail levels of an image. Low detail images consume fewer
+// tokens, while high detail images let the model see finer detail.
+const (
+	ImageDetailAuto = "auto"
+	ImageDetailLow  = "low"
+	ImageDetailHigh = "high"
+)
+
+// The formats of InputAudio.
+const (
+	AudioFormatWAV = "wav"
+	AudioFormatMP3 = "mp3"
+)
+
+// A part of a multimodal message. See Chat.ContentParts.
+type ContentPart struct {
+	// One of ContentPartText, ContentPartImageURL,
+	// ContentPartInputAudio or ContentPartFile.
+	Type string `json:"type"`
+
+	// The text of a ContentPartText part.
+	Text string `json:"text,omitempty"`
+
+	// The image of a ContentPartImageURL part.
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+
+	// The audio of a ContentPartInputAudio part.
+	InputAudio *InputAudio `json:"input_audio,omitempty"`
+
+	// The file of a ContentPartFile part.
+	File *ContentFile `json:"file,omitempty"`
+}
+
+type ImageURL struct {
+	// Either a URL of the image, or the base64 encoded image data as a data URL.
+	URL string `json:"url"`
+
+	// One of ImageDetailAuto, ImageDetailLow or ImageDetailHigh.
+	// Defaults to auto.
+	Detail string `json:"detail,omitempty"`
+}
+
+type InputAudio struct {
+	// Base64 encoded audio data.
+	Data string `json:"data"`
+
+	// One of AudioFormatWAV or AudioFormatMP3.
+	Format string `json:"format"`
+}
+
+// A file attached to a message, either previously uploaded
+// with the files package, or embedded in the message.
+type ContentFile struct {
+	// The ID of an uploaded file.
+	FileID string `json:"file_id,omitempty"`
+
+	// The name of an embedded file.
+	Filename string `json:"filename,omitempty"`
+
+	// The base64 encoded data of an embedded file.
+	FileData string `json:"file_data,omitempty"`
+}
+
+// NewTextPart creates a ContentPartText part.
+func NewTextPart(text string) ContentPart {
+	return ContentPart{Type: ContentPartText, Text: text}
+}
+
+// NewImageURLPart creates a ContentPartImageURL part referring to
+// an image by its URL, which may be a data URL.
+func NewImageURLPart(url, detail string) ContentPart {
+	return ContentPart{
+		Type:     ContentPartImageURL,
+		ImageURL: &ImageURL{URL: url, Detail: detail},
+	}
+}
+
+// NewImagePart creates a ContentPartImageURL part from an image, which
+// may either be the path of a local file or a URL. Local files are
+// embedded in the message as a base64 data URL, while remote images are
+// retrieved by the API.
+func NewImagePart(filepath, detail string) (ContentPart, error) {
+	if common.IsUrl(filepath) || strings.HasPrefix(filepath, "data:") {
+		return NewImageURLPart(filepath, detail), nil
+	}
+	file, err := os.Open(filepath)
+	if err != nil {
+		return ContentPart{}, err
+	}
+	defer file.Close()
+	return NewImagePartFromReader(file, detail)
+}
+
+// NewImagePartFromReader creates a ContentPartImageURL part embedding
+// the image read from r as a base64 data URL. The MIME type of the
+// image is detected from its content.
+func NewImagePartFromReader(r io.Reader, detail string) (ContentPart, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return ContentPart{}, err
+	}
+	if len(data) == 0 {
+		return ContentPart{}, errors.New("empty image provided")
+	}
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return ContentPart{}, errors.New("unrecognized image type " + mimeType)
+	}
+	return NewImageURLPart("data:"+mimeType+";base64,"+base64.StdEncoding.EncodeToString(data), detail), nil
+}
+
+// NewInputAudioPart creates a ContentPartInputAudio part from the
+// given audio data, in one of AudioFormatWAV or AudioFormatMP3.
+func NewInputAudioPart(data []byte, format string) ContentPart {
+	return ContentPart{
+		Type: ContentPartInputAudio,
+		InputAudio: &InputAudio{
+			Data:   base64.StdEncoding.EncodeToString(data),
+			Format: format,
+		},
+	}
+}
+
+// NewFilePart creates a ContentPartFile part referring to a file
+// uploaded with the files package.
+func NewFilePart(fileID string) ContentPart {
+	return ContentPart{
+		Type: ContentPartFile,
+		File: &ContentFile{FileID: fileID},
+	}
+}
+
+// NewMultipartMessage creates a message with the given role, whose
+// content consists of the given parts.
+func NewMultipartMessage(role Role, parts ...ContentPart) Chat {
+	return Chat{Role: role, ContentParts: parts}
+}
+
+// Text returns the text content of the message. For a multipart
+// message, this is the concatenation of its text parts.
+func (c *Chat) Text() string {
+	if len(c.ContentParts) == 0 {
+		return c.Content
+	}
+	text := strings.Builder{}
+	for _, part := range c.ContentParts {
+		if part.Type == ContentPartText {
+			text.WriteString(part.Text)
+		}
+	}
+	return text.String()
+}
+
+type chatAlias Chat
+
+// MarshalJSON encodes the content of the message as an array of parts
+// if ContentParts is set, and as a string otherwise.
+func (c Chat) MarshalJSON() ([]byte, error) {
+	if len(c.ContentParts) == 0 {
+		return json.Marshal(chatAlias(c))
+	}
+	return json.Marshal(struct {
+		chatAlias
+		Content []ContentPart `json:"content"`
+	}{
+		chatAlias: chatAlias(c),
+		Content:   c.ContentParts,
+	})
+}
+
+// UnmarshalJSON decodes content sent either as a string, or as an array
+// of parts. In the latter case, ContentParts is set, and Content is set
+// to the concatenation of the text parts.
+func (c *Chat) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*chatAlias
+		Content json.RawMessage `json:"content"`
+	}{
+		chatAlias: (*chatAlias)(c),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	c.Content = ""
+	c.ContentParts = nil
+	switch {
+	case len(aux.Content) == 0 || string(aux.Content) == "null":
+	case aux.Content[0] == '[':
+		if err := json.Unmarshal(aux.Content, &c.ContentParts); err != nil {
+			return err
+		}
+		c.Content = c.Text()
+	default:
+		if err := json.Unmarshal(aux.Content, &c.Content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package chat_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kardbord/gopenai/chat"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestContentPartsJSON(t *testing.T) {
+	message := chat.NewMultipartMessage(chat.UserRole,
+		chat.NewTextPart("What is in this image?"),
+		chat.NewImageURLPart("https://example.com/image.png", chat.ImageDetailLow),
+		chat.NewFilePart("file-123"),
+	)
+	data, err := json.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"role":"user","content":[` +
+		`{"type":"text","text":"What is in this image?"},` +
+		`{"type":"image_url","image_url":{"url":"https://example.com/image.png","detail":"low"}},` +
+		`{"type":"file","file":{"file_id":"file-123"}}]}`
+	if string(data) != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, data)
+	}
+
+	decoded := chat.Chat{}
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.ContentParts) != 3 || decoded.Content != "What is in this image?" || decoded.ContentParts[2].File.FileID != "file-123" {
+		t.Fatalf("unexpected message: %+v", decoded)
+	}
+
+	// The simple string form is unchanged.
+	data, err = json.Marshal(chat.Chat{Role: chat.UserRole, Content: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"content":"Hello","role":"user"}` {
+		t.Fatalf("unexpected message: %s", data)
+	}
+	decoded = chat.Chat{}
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Content != "Hello" || decoded.ContentParts != nil {
+		t.Fatalf("unexpected message: %+v", decoded)
+	}
+}
+
+func TestImagePart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.png")
+	if err := os.WriteFile(path, pngHeader, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	part, err := chat.NewImagePart(path, chat.ImageDetailHigh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.Type != chat.ContentPartImageURL || !strings.HasPrefix(part.ImageURL.URL, "data:image/png;base64,") || part.ImageURL.Detail != chat.ImageDetailHigh {
+		t.Fatalf("unexpected part: %+v", part.ImageURL)
+	}
+
+	part, err = chat.NewImagePart("https://example.com/image.jpg", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if part.ImageURL.URL != "https://example.com/image.jpg" {
+		t.Fatalf("unexpected part: %+v", part.ImageURL)
+	}
+
+	if _, err = chat.NewImagePartFromReader(strings.NewReader("not an image"), ""); err == nil {
+		t.Fatal("expected an error for a non-image")
+	}
+}
@@ -0,0 +1,69 @@
+package chat
+
+// The finish reason of a choice whose content was omitted by a content filter.
+const FinishReasonContentFilter = "content_filter"
+
+// The result of a content filter category, such as hate or violence.
+// Content filter results are only included in responses from the
+// Azure OpenAI service.
+type ContentFilterResult struct {
+	Filtered bool `json:"filtered"`
+
+	// One of "safe", "low", "medium" or "high".
+	Severity string `json:"severity,omitempty"`
+}
+
+// The result of a content filter which detects content, such as a jailbreak
+// attempt or protected material, rather than grading its severity.
+type ContentFilterDetection struct {
+	Filtered bool `json:"filtered"`
+	Detected bool `json:"detected"`
+}
+
+// The results of the content filters applied to a prompt or a choice by
+// the Azure OpenAI service. Categories which were not evaluated are nil.
+type ContentFilterResults struct {
+	Hate     *ContentFilterResult `json:"hate,omitempty"`
+	SelfHarm *ContentFilterResult `json:"self_harm,omitempty"`
+	Sexual   *ContentFilterResult `json:"sexual,omitempty"`
+	Violence *ContentFilterResult `json:"violence,omitempty"`
+
+	Profanity             *ContentFilterDetection `json:"profanity,omitempty"`
+	Jailbreak             *ContentFilterDetection `json:"jailbreak,omitempty"`
+	IndirectAttack        *ContentFilterDetection `json:"indirect_attack,omitempty"`
+	ProtectedMaterialText *ContentFilterDetection `json:"protected_material_text,omitempty"`
+	ProtectedMaterialCode *ContentFilterDetection `json:"protected_material_code,omitempty"`
+
+	// Set if the content filters could not be applied.
+	Error *ContentFilterError `json:"error,omitempty"`
+}
+
+// An error which prevented the content filters from being applied.
+type ContentFilterError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// The content filter results of one of the prompts of a request.
+type PromptFilterResult struct {
+	PromptIndex          int64                `json:"prompt_index"`
+	ContentFilterResults ContentFilterResults `json:"content_filter_results"`
+}
+
+// Filtered reports whether any content filter category filtered the content.
+func (r *ContentFilterResults) Filtered() bool {
+	if r == nil {
+		return false
+	}
+	for _, result := range []*ContentFilterResult{r.Hate, r.SelfHarm, r.Sexual, r.Violence} {
+		if result != nil && result.Filtered {
+			return true
+		}
+	}
+	for _, detection := range []*ContentFilterDetection{r.Profanity, r.Jailbreak, r.IndirectAttack, r.ProtectedMaterialText, r.ProtectedMaterialCode} {
+		if detection != nil && detection.Filtered {
+			return true
+		}
+	}
+	return false
+}
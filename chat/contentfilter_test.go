@@ -0,0 +1,31 @@
+package chat_test
+
+import (
+	"testing"
+
+	"github.com/Kardbord/gopenai/chat"
+)
+
+func TestAccumulateContentFilterResults(t *testing.T) {
+	safe := &chat.ContentFilterResults{Hate: &chat.ContentFilterResult{Severity: "safe"}}
+	filtered := &chat.ContentFilterResults{Violence: &chat.ContentFilterResult{Filtered: true, Severity: "high"}}
+
+	acc := chat.Accumulator{}
+	acc.Add(&chat.StreamChunk{PromptFilterResults: []chat.PromptFilterResult{{PromptIndex: 0, ContentFilterResults: *safe}}})
+	acc.Add(&chat.StreamChunk{Choices: []chat.StreamChoice{{Delta: chat.Delta{Content: "Hi"}, ContentFilterResults: safe}}})
+	acc.Add(&chat.StreamChunk{Choices: []chat.StreamChoice{{FinishReason: chat.FinishReasonContentFilter, ContentFilterResults: filtered}}})
+	acc.Add(&chat.StreamChunk{Choices: []chat.StreamChoice{{ContentFilterResults: safe}}})
+
+	resp := acc.Response()
+	if len(resp.PromptFilterResults) != 1 || resp.PromptFilterResults[0].ContentFilterResults.Filtered() {
+		t.Fatalf("unexpected prompt filter results: %+v", resp.PromptFilterResults)
+	}
+	if results := resp.Choices[0].ContentFilterResults; !results.Filtered() || results.Violence.Severity != "high" {
+		t.Fatalf("unexpected content filter results: %+v", results)
+	}
+
+	var none *chat.ContentFilterResults
+	if none.Filtered() {
+		t.Fatal("nil results should not be filtered")
+	}
+}
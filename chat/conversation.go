@@ -0,0 +1,304 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kardbord/gopenai/common"
+)
+
+// A TokenCounter counts the tokens a message consumes in a request.
+type TokenCounter func(message *Chat) int64
+
+// EstimateMessageTokens roughly estimates the tokens consumed by message,
+// including the few tokens of formatting which wrap every message.
+// It is the default TokenCounter of a Conversation.
+func EstimateMessageTokens(message *Chat) int64 {
+	tokens := 4 + common.EstimateTokens(message.Text())
+	for _, call := range message.ToolCalls {
+		tokens += common.EstimateTokens(call.Function.Name) + common.EstimateTokens(call.Function.Arguments)
+	}
+	return tokens
+}
+
+// A TruncationStrategy shortens the history of a Conversation so that it
+// fits within its context window. Truncate returns messages, shortened so
+// that their total token count, as measured by count, is no more than
+// maxTokens. The last message, which the model responds to, must be kept,
+// so if the history cannot fit without it, Truncate returns an error.
+type TruncationStrategy interface {
+	Truncate(ctx context.Context, messages []Chat, maxTokens int64, count TokenCounter) ([]Chat, error)
+}
+
+// DropOldest is a TruncationStrategy which drops the oldest messages,
+// including system messages, until the history fits. The last message
+// is never dropped.
+type DropOldest struct{}
+
+// Truncate implements TruncationStrategy.
+func (DropOldest) Truncate(ctx context.Context, messages []Chat, maxTokens int64, count TokenCounter) ([]Chat, error) {
+	return dropOldest(messages, 0, maxTokens, count)
+}
+
+// KeepSystemPrompt is a TruncationStrategy which drops the oldest messages
+// until the history fits, except for the system messages at the start of
+// the conversation and the last message, which are always kept.
+type KeepSystemPrompt struct{}
+
+// Truncate implements TruncationStrategy.
+func (KeepSystemPrompt) Truncate(ctx context.Context, messages []Chat, maxTokens int64, count TokenCounter) ([]Chat, error) {
+	return dropOldest(messages, leadingSystemMessages(messages), maxTokens, count)
+}
+
+// The default prompt used by Summarize.
+const DefaultSummaryPrompt = "Summarize the following conversation concisely, preserving every fact, " +
+	"decision and open question needed to continue it."
+
+// Summarize is a TruncationStrategy which replaces older messages with a
+// summary generated by the model. The system messages at the start of the
+// conversation and the most recent messages are kept as is. If the history
+// still does not fit once summarized, the oldest messages after the summary
+// are dropped, as with KeepSystemPrompt.
+type Summarize struct {
+	// The model used to generate the summary.
+	Model string
+
+	// The client used to send the summary request.
+	// If nil, the DefaultClient is used.
+	Client *common.Client
+
+	// The organization ID sent with the summary request. May be nil.
+	OrganizationID *string
+
+	// The number of most recent messages which are never summarized.
+	// The last message, which the model responds to, is always kept.
+	// Must not be negative.
+	KeepRecent int
+
+	// The instructions given to the model. Defaults to DefaultSummaryPrompt.
+	Prompt string
+}
+
+// Truncate implements TruncationStrategy.
+func (s *Summarize) Truncate(ctx context.Context, messages []Chat, maxTokens int64, count TokenCounter) ([]Chat, error) {
+	if totalTokens(messages, count) <= maxTokens {
+		return messages, nil
+	}
+
+	if s.KeepRecent < 0 {
+		return messages, fmt.Errorf("invalid KeepRecent %d: must not be negative", s.KeepRecent)
+	}
+
+	pinned := leadingSystemMessages(messages)
+	split := len(messages) - max(s.KeepRecent, 1)
+	// Tool results must follow the tool calls they respond to.
+	for split > pinned && split < len(messages) && messages[split].Role == ToolRole {
+		split--
+	}
+	if split <= pinned {
+		return dropOldest(messages, pinned, maxTokens, count)
+	}
+
+	prompt := s.Prompt
+	if len(prompt) == 0 {
+		prompt = DefaultSummaryPrompt
+	}
+	transcript := strings.Builder{}
+	for i := pinned; i < split; i++ {
+		m := &messages[i]
+		fmt.Fprintf(&transcript, "%s: %s\n", m.Role, m.Text())
+		for _, call := range m.ToolCalls {
+			fmt.Fprintf(&transcript, "%s called %s(%s)\n", m.Role, call.Function.Name, call.Function.Arguments)
+		}
+	}
+
+	resp, err := MakeRequestWithClientContext(ctx, s.Client, &Request{
+		Model: s.Model,
+		Messages: []Chat{
+			{Role: SystemRole, Content: prompt},
+			{Role: UserRole, Content: transcript.String()},
+		},
+	}, s.OrganizationID)
+	if err != nil {
+		return messages, fmt.Errorf("unable to summarize conversation: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return messages, errors.New("unable to summarize conversation: no choices in response")
+	}
+
+	summarized := make([]Chat, 0, pinned+1+len(messages)-split)
+	summarized = append(summarized, messages[:pinned]...)
+	summarized = append(summarized, Chat{
+		Role:    SystemRole,
+		Content: "Summary of the earlier conversation:\n" + resp.Choices[0].Message.Content,
+	})
+	summarized = append(summarized, messages[split:]...)
+	truncated, err := dropOldest(summarized, pinned+1, maxTokens, count)
+	if err != nil {
+		return messages, err
+	}
+	return truncated, nil
+}
+
+func leadingSystemMessages(messages []Chat) int {
+	n := 0
+	for n < len(messages) && messages[n].Role == SystemRole {
+		n++
+	}
+	return n
+}
+
+func totalTokens(messages []Chat, count TokenCounter) int64 {
+	var total int64
+	for i := range messages {
+		total += count(&messages[i])
+	}
+	return total
+}
+
+// dropOldest drops the oldest messages after the first pinned messages
+// until the total fits within maxTokens. Tool results left without the
+// tool call they respond to are dropped as well. The last message, along
+// with the tool call it responds to, is never dropped, so an error is
+// returned if it does not fit with the pinned messages.
+func dropOldest(messages []Chat, pinned int, maxTokens int64, count TokenCounter) ([]Chat, error) {
+	last := len(messages) - 1
+	for last > pinned && messages[last].Role == ToolRole {
+		last--
+	}
+	total := totalTokens(messages, count)
+	drop := pinned
+	for drop < last && (total > maxTokens || messages[drop].Role == ToolRole) {
+		total -= count(&messages[drop])
+		drop++
+	}
+	if total > maxTokens {
+		return messages, fmt.Errorf("conversation does not fit within %d tokens: the messages which are always kept take %d", maxTokens, total)
+	}
+	if drop == pinned {
+		return messages, nil
+	}
+	truncated := make([]Chat, 0, pinned+len(messages)-drop)
+	truncated = append(truncated, messages[:pinned]...)
+	return append(truncated, messages[drop:]...), nil
+}
+
+// A Conversation owns the message history of a long-running chat session.
+// Each call to Send appends the given messages to the history, truncates
+// the history to fit within the context window, sends it to the model,
+// and appends the model's reply.
+//
+// A Conversation is not safe for concurrent use.
+type Conversation struct {
+	// The parameters of each request, such as the model.
+	// Its Messages are ignored.
+	Request Request
+
+	// The client used to send requests. If nil, the DefaultClient is used.
+	Client *common.Client
+
+	// The organization ID sent with each request. May be nil.
+	OrganizationID *string
+
+	// The size of the model's context window, in tokens. The tokens
+	// reserved for the reply by Request.MaxTokens are subtracted from it.
+	// If zero, the history is never truncated.
+	MaxContextTokens int64
+
+	// How the history is truncated. Defaults to KeepSystemPrompt.
+	Strategy TruncationStrategy
+
+	// How the tokens of each message are counted.
+	// Defaults to EstimateMessageTokens.
+	TokenCounter TokenCounter
+
+	messages []Chat
+}
+
+// Messages returns a copy of the message history.
+func (c *Conversation) Messages() []Chat {
+	return append([]Chat{}, c.messages...)
+}
+
+// Append adds messages to the history, without sending them.
+func (c *Conversation) Append(messages ...Chat) {
+	c.messages = append(c.messages, messages...)
+}
+
+// AppendResponse adds the message of the first choice in resp to the
+// history. Send does this automatically; AppendResponse is useful when
+// the request was made some other way, such as MakeStreamingRequest.
+func (c *Conversation) AppendResponse(resp *Response) {
+	if resp != nil && len(resp.Choices) != 0 {
+		c.messages = append(c.messages, resp.Choices[0].Message)
+	}
+}
+
+// Reset clears the history.
+func (c *Conversation) Reset() {
+	c.messages = nil
+}
+
+func (c *Conversation) tokenCounter() TokenCounter {
+	if c.TokenCounter == nil {
+		return EstimateMessageTokens
+	}
+	return c.TokenCounter
+}
+
+// Tokens returns the number of tokens in the history.
+func (c *Conversation) Tokens() int64 {
+	return totalTokens(c.messages, c.tokenCounter())
+}
+
+// Truncate applies the TruncationStrategy to the history, so that it fits
+// within the context window. Send does this automatically.
+func (c *Conversation) Truncate(ctx context.Context) error {
+	if c.MaxContextTokens <= 0 {
+		return nil
+	}
+	maxTokens := c.MaxContextTokens
+	if c.Request.MaxTokens != nil {
+		maxTokens -= *c.Request.MaxTokens
+	}
+	count := c.tokenCounter()
+	if totalTokens(c.messages, count) <= maxTokens {
+		return nil
+	}
+
+	strategy := c.Strategy
+	if strategy == nil {
+		strategy = KeepSystemPrompt{}
+	}
+	messages, err := strategy.Truncate(ctx, c.messages, maxTokens, count)
+	if err != nil {
+		return err
+	}
+	c.messages = messages
+	return nil
+}
+
+// Send appends messages to the history, then sends the history to the model,
+// appending its reply. The history is truncated first if it does not fit
+// within the context window. If the request fails, the messages remain in
+// the history, so Send may be retried without any arguments.
+func (c *Conversation) Send(ctx context.Context, messages ...Chat) (*Response, error) {
+	c.Append(messages...)
+	if len(c.messages) == 0 {
+		return nil, errors.New("no messages to send")
+	}
+	if err := c.Truncate(ctx); err != nil {
+		return nil, err
+	}
+
+	request := c.Request
+	request.Messages = c.messages
+	resp, err := MakeRequestWithClientContext(ctx, c.Client, &request, c.OrganizationID)
+	if err != nil {
+		return resp, err
+	}
+	c.AppendResponse(resp)
+	return resp, nil
+}
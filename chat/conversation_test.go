@@ -0,0 +1,197 @@
+package chat_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/common"
+)
+
+// Counts every message as 10 tokens, for predictable truncation.
+func countTen(*chat.Chat) int64 {
+	return 10
+}
+
+func contents(messages []chat.Chat) string {
+	s := []string{}
+	for _, m := range messages {
+		s = append(s, m.Content)
+	}
+	return strings.Join(s, ",")
+}
+
+func testHistory() []chat.Chat {
+	return []chat.Chat{
+		{Role: chat.SystemRole, Content: "system"},
+		{Role: chat.UserRole, Content: "u1"},
+		{Role: chat.AssistantRole, Content: "a1", ToolCalls: []chat.ToolCall{{ID: "call", Type: chat.ToolTypeFunction}}},
+		chat.NewToolMessage("call", "t1"),
+		{Role: chat.AssistantRole, Content: "a2"},
+		{Role: chat.UserRole, Content: "u2"},
+	}
+}
+
+func TestTruncationStrategies(t *testing.T) {
+	ctx := context.Background()
+
+	messages, err := chat.DropOldest{}.Truncate(ctx, testHistory(), 30, countTen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Dropping the tool call also drops its result.
+	if c := contents(messages); c != "a2,u2" {
+		t.Fatalf("unexpected history: %s", c)
+	}
+
+	messages, err = chat.KeepSystemPrompt{}.Truncate(ctx, testHistory(), 40, countTen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := contents(messages); c != "system,a2,u2" {
+		t.Fatalf("unexpected history: %s", c)
+	}
+
+	messages, err = chat.KeepSystemPrompt{}.Truncate(ctx, testHistory(), 60, countTen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 6 {
+		t.Fatalf("expected the history to be unchanged, got %s", contents(messages))
+	}
+
+	// The last message is never dropped, along with the tool call it responds to.
+	messages, err = chat.DropOldest{}.Truncate(ctx, testHistory()[:4], 10, countTen)
+	if err == nil {
+		t.Fatalf("expected an error, got %s", contents(messages))
+	}
+	messages, err = chat.DropOldest{}.Truncate(ctx, testHistory()[:4], 20, countTen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := contents(messages); c != "a1,t1" {
+		t.Fatalf("unexpected history: %s", c)
+	}
+	messages, err = chat.DropOldest{}.Truncate(ctx, testHistory(), 5, countTen)
+	if err == nil {
+		t.Fatalf("expected an error rather than an empty history, got %s", contents(messages))
+	}
+	messages, err = chat.KeepSystemPrompt{}.Truncate(ctx, testHistory(), 10, countTen)
+	if err == nil {
+		t.Fatalf("expected an error rather than dropping the last message, got %s", contents(messages))
+	}
+}
+
+func newConversationServer(t *testing.T, requests *[]chat.Request) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := chat.Request{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		*requests = append(*requests, req)
+
+		reply := "reply"
+		if req.Messages[0].Content == chat.DefaultSummaryPrompt {
+			reply = "summary"
+		}
+		w.Write([]byte(`{"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"` + reply + `"}}]}`))
+	}))
+}
+
+func TestConversation(t *testing.T) {
+	requests := []chat.Request{}
+	server := newConversationServer(t, &requests)
+	defer server.Close()
+
+	conv := chat.Conversation{
+		Request:          chat.Request{Model: "gpt-4o"},
+		Client:           common.NewClient(common.WithAPIKey("key"), common.WithBaseURL(server.URL+"/v1")),
+		MaxContextTokens: 40,
+		TokenCounter:     countTen,
+	}
+	conv.Append(chat.Chat{Role: chat.SystemRole, Content: "system"})
+
+	for _, content := range []string{"u1", "u2", "u3"} {
+		if _, err := conv.Send(context.Background(), chat.Chat{Role: chat.UserRole, Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if c := contents(requests[2].Messages); c != "system,u2,reply,u3" {
+		t.Fatalf("unexpected request: %s", c)
+	}
+	if c := contents(conv.Messages()); c != "system,u2,reply,u3,reply" {
+		t.Fatalf("unexpected history: %s", c)
+	}
+	if tokens := conv.Tokens(); tokens != 50 {
+		t.Fatalf("expected 50 tokens, got %d", tokens)
+	}
+
+	// A message which cannot fit with the system prompt is not sent without it.
+	conv.MaxContextTokens = 15
+	if _, err := conv.Send(context.Background(), chat.Chat{Role: chat.UserRole, Content: "u4"}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(requests) != 3 {
+		t.Fatalf("expected no request to be sent, got %d requests", len(requests))
+	}
+}
+
+func TestConversationSummarize(t *testing.T) {
+	requests := []chat.Request{}
+	server := newConversationServer(t, &requests)
+	defer server.Close()
+
+	client := common.NewClient(common.WithAPIKey("key"), common.WithBaseURL(server.URL+"/v1"))
+	conv := chat.Conversation{
+		Request:          chat.Request{Model: "gpt-4o"},
+		Client:           client,
+		MaxContextTokens: 50,
+		TokenCounter:     countTen,
+		Strategy:         &chat.Summarize{Model: "gpt-4o-mini", Client: client, KeepRecent: 2},
+	}
+	conv.Append(testHistory()...)
+
+	if _, err := conv.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 2 || requests[0].Model != "gpt-4o-mini" {
+		t.Fatalf("expected a summary request, got %+v", requests)
+	}
+	if !strings.Contains(requests[0].Messages[1].Content, "assistant called") {
+		t.Fatalf("expected the transcript to include tool calls: %s", requests[0].Messages[1].Content)
+	}
+	messages := requests[1].Messages
+	if len(messages) != 4 || !strings.HasSuffix(messages[1].Content, "summary") || contents(messages[2:]) != "a2,u2" {
+		t.Fatalf("unexpected request: %s", contents(messages))
+	}
+}
+
+func TestSummarizeKeepRecent(t *testing.T) {
+	requests := []chat.Request{}
+	server := newConversationServer(t, &requests)
+	defer server.Close()
+	client := common.NewClient(common.WithAPIKey("key"), common.WithBaseURL(server.URL+"/v1"))
+
+	// The last message is kept even if KeepRecent is not set.
+	messages, err := (&chat.Summarize{Model: "gpt-4o-mini", Client: client}).Truncate(context.Background(), testHistory(), 40, countTen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 || !strings.HasSuffix(messages[1].Content, "summary") || messages[2].Content != "u2" {
+		t.Fatalf("unexpected history: %s", contents(messages))
+	}
+	if strings.Contains(requests[0].Messages[1].Content, "u2") {
+		t.Fatalf("expected the last message not to be summarized: %s", requests[0].Messages[1].Content)
+	}
+
+	_, err = (&chat.Summarize{Model: "gpt-4o-mini", Client: client, KeepRecent: -1}).Truncate(context.Background(), testHistory(), 40, countTen)
+	if err == nil {
+		t.Fatal("expected an error for a negative KeepRecent")
+	}
+}
@@ -0,0 +1,106 @@
+package chat
+
+import (
+	"math"
+	"strings"
+)
+
+// One of the most likely tokens at a position, with its log probability.
+type TopLogProb struct {
+	Token string `json:"token"`
+
+	// The log probability of the token.
+	LogProb float64 `json:"logprob"`
+
+	// The UTF-8 bytes of the token. Useful when a character is represented
+	// by multiple tokens, whose bytes must be combined to decode it.
+	// Nil if there is no bytes representation of the token.
+	Bytes []int `json:"bytes"`
+}
+
+// An output token, with its log probability and the most likely
+// tokens at its position.
+type TokenLogProb struct {
+	Token   string  `json:"token"`
+	LogProb float64 `json:"logprob"`
+
+	// See TopLogProb.Bytes.
+	Bytes []int `json:"bytes"`
+
+	// The most likely tokens at this position, up to Request.TopLogProbs.
+	// In rare cases, fewer may be returned.
+	TopLogProbs []TopLogProb `json:"top_logprobs"`
+}
+
+// Log probability information for a Choice.
+type LogProbs struct {
+	// The message content tokens.
+	Content []TokenLogProb `json:"content"`
+
+	// The message refusal tokens.
+	Refusal []TokenLogProb `json:"refusal"`
+}
+
+// Probability returns the probability of the token, between 0 and 1.
+func (t *TokenLogProb) Probability() float64 {
+	return math.Exp(t.LogProb)
+}
+
+// LabelProbabilities returns the probability distribution over the given
+// labels at this token position, which is typically the first token of a
+// classification response. The probability of each label is taken from
+// TopLogProbs, ignoring surrounding whitespace, and the distribution is
+// normalized to sum to 1. Labels which are not among the most likely
+// tokens have a probability of 0. If no label is present, every label has
+// a probability of 0.
+//
+// Each label is matched against a single token, so labels should be
+// chosen to tokenize as one token, such as "positive" and "negative".
+func (t *TokenLogProb) LabelProbabilities(labels ...string) map[string]float64 {
+	dist := make(map[string]float64, len(labels))
+	for _, label := range labels {
+		dist[label] = 0
+	}
+
+	candidates := append([]TopLogProb{{Token: t.Token, LogProb: t.LogProb}}, t.TopLogProbs...)
+	seen := map[string]bool{}
+	total := 0.0
+	for _, c := range candidates {
+		token := strings.TrimSpace(c.Token)
+		if _, ok := dist[token]; !ok || seen[c.Token] {
+			continue
+		}
+		seen[c.Token] = true
+		p := math.Exp(c.LogProb)
+		dist[token] += p
+		total += p
+	}
+
+	if total > 0 {
+		for label := range dist {
+			dist[label] /= total
+		}
+	}
+	return dist
+}
+
+// LogLikelihood returns the log probability of the whole message
+// content, which is the sum of the log probabilities of its tokens.
+func (l *LogProbs) LogLikelihood() float64 {
+	sum := 0.0
+	for _, t := range l.Content {
+		sum += t.LogProb
+	}
+	return sum
+}
+
+// Perplexity returns the perplexity of the message content, which is
+// the exponential of the negative mean log probability of its tokens.
+// Lower values indicate that the model was more confident in its output.
+// The perplexity of empty content is 1.
+func (l *LogProbs) Perplexity() float64 {
+	if len(l.Content) == 0 {
+		return 1
+	}
+	return math.Exp(-l.LogLikelihood() / float64(len(l.Content)))
+}
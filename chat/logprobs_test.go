@@ -0,0 +1,51 @@
+package chat_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/Kardbord/gopenai/chat"
+)
+
+const logProbsJSON = `{"content":[
+	{"token":"positive","logprob":-0.2,"bytes":[112,111,115,105,116,105,118,101],"top_logprobs":[
+		{"token":"positive","logprob":-0.2,"bytes":[112,111,115,105,116,105,118,101]},
+		{"token":" negative","logprob":-1.8,"bytes":null},
+		{"token":"neutral","logprob":-3.0,"bytes":null}]},
+	{"token":".","logprob":-0.4,"bytes":[46],"top_logprobs":[]}],
+	"refusal":null}`
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestLogProbs(t *testing.T) {
+	lp := chat.LogProbs{}
+	if err := json.Unmarshal([]byte(logProbsJSON), &lp); err != nil {
+		t.Fatal(err)
+	}
+
+	if ll := lp.LogLikelihood(); !almostEqual(ll, -0.6) {
+		t.Fatalf("expected log-likelihood -0.6, got %f", ll)
+	}
+	if p := lp.Perplexity(); !almostEqual(p, math.Exp(0.3)) {
+		t.Fatalf("expected perplexity %f, got %f", math.Exp(0.3), p)
+	}
+	if p := lp.Content[0].Probability(); !almostEqual(p, math.Exp(-0.2)) {
+		t.Fatalf("unexpected probability %f", p)
+	}
+
+	dist := lp.Content[0].LabelProbabilities("positive", "negative", "unknown")
+	total := math.Exp(-0.2) + math.Exp(-1.8)
+	if !almostEqual(dist["positive"], math.Exp(-0.2)/total) ||
+		!almostEqual(dist["negative"], math.Exp(-1.8)/total) ||
+		dist["unknown"] != 0 {
+		t.Fatalf("unexpected distribution: %v", dist)
+	}
+
+	empty := chat.LogProbs{}
+	if p := empty.Perplexity(); p != 1 {
+		t.Fatalf("expected perplexity of empty content to be 1, got %f", p)
+	}
+}
@@ -0,0 +1,280 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Kardbord/gopenai/common"
+	"github.com/Kardbord/gopenai/jsonschema"
+)
+
+// The default value of Runner.MaxIterations.
+const DefaultMaxIterations = 10
+
+// Returned by Runner.Run when the model is still calling tools
+// after Runner.MaxIterations requests.
+var ErrMaxIterations = errors.New("maximum number of tool call iterations exceeded")
+
+// A ToolRegistry holds Go functions which the model may call as tools.
+// Functions are added with RegisterTool. A ToolRegistry is safe for
+// concurrent use.
+type ToolRegistry struct {
+	tools map[string]registeredTool
+	names []string
+	mutex sync.RWMutex
+}
+
+type registeredTool struct {
+	definition FunctionDefinition
+	call       func(ctx context.Context, arguments string) (string, error)
+}
+
+// NewToolRegistry creates an empty ToolRegistry.
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{tools: map[string]registeredTool{}}
+}
+
+// RegisterTool adds fn to the registry as a tool described by definition.
+// When the model calls the tool, its arguments are decoded from JSON into
+// an Args, and the Result returned by fn is sent back to the model. A string
+// Result is sent as is; any other Result is encoded as JSON.
+//
+// If definition.Parameters is nil, a JSON Schema is generated from Args,
+// as described by the jsonschema package.
+//
+// An error is returned if definition has no name, or if a tool with
+// the same name has already been registered.
+func RegisterTool[Args, Result any](registry *ToolRegistry, definition FunctionDefinition, fn func(ctx context.Context, args Args) (Result, error)) error {
+	if len(definition.Name) == 0 {
+		return errors.New("tool definition has no name")
+	}
+	if fn == nil {
+		return fmt.Errorf("nil function provided for tool %q", definition.Name)
+	}
+	if definition.Parameters == nil {
+		schema, err := jsonschema.Generate[Args]()
+		if err != nil {
+			return fmt.Errorf("tool %q: %w", definition.Name, err)
+		}
+		definition.Parameters = schema
+	}
+
+	call := func(ctx context.Context, arguments string) (string, error) {
+		var args Args
+		if len(arguments) != 0 {
+			if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+		}
+		result, err := fn(ctx, args)
+		if err != nil {
+			return "", err
+		}
+		if s, ok := any(result).(string); ok {
+			return s, nil
+		}
+		data, err := json.Marshal(result)
+		if err != nil {
+			return "", fmt.Errorf("unable to encode result: %w", err)
+		}
+		return string(data), nil
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if _, ok := registry.tools[definition.Name]; ok {
+		return fmt.Errorf("tool %q is already registered", definition.Name)
+	}
+	registry.tools[definition.Name] = registeredTool{definition: definition, call: call}
+	registry.names = append(registry.names, definition.Name)
+	return nil
+}
+
+// Tools returns the registered tools, in the order they were registered,
+// for use in Request.Tools.
+func (r *ToolRegistry) Tools() []Tool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	tools := make([]Tool, len(r.names))
+	for i, name := range r.names {
+		tools[i] = NewFunctionTool(r.tools[name].definition)
+	}
+	return tools
+}
+
+// Call runs the registered tool requested by call, returning its result.
+func (r *ToolRegistry) Call(ctx context.Context, call ToolCall) (result string, err error) {
+	r.mutex.RLock()
+	tool, ok := r.tools[call.Function.Name]
+	r.mutex.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", call.Function.Name)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("tool %q panicked: %v", call.Function.Name, p)
+		}
+	}()
+	return tool.call(ctx, call.Function.Arguments)
+}
+
+// A Runner automates the conversation between the model and the tools in
+// its Registry. Run sends a request, runs every tool the model calls,
+// sends the results back to the model, and repeats until the model
+// responds without calling a tool.
+//
+// Errors returned by tools, calls to unknown tools and malformed arguments
+// are not fatal. They are reported to the model as the result of the tool
+// call, so that it may correct itself.
+type Runner struct {
+	// The tools the model may call.
+	Registry *ToolRegistry
+
+	// The client used to send requests. If nil, the DefaultClient is used.
+	Client *common.Client
+
+	// The organization ID sent with each request. May be nil.
+	OrganizationID *string
+
+	// The maximum number of requests sent by a single call to Run.
+	// Defaults to DefaultMaxIterations.
+	MaxIterations int
+
+	// The maximum number of tool calls run concurrently. When the model
+	// requests several tool calls in a single response, they are run in
+	// parallel. Values less than 1 leave concurrency unlimited.
+	MaxParallelToolCalls int
+}
+
+// The outcome of a single tool call made during a Run.
+type ToolResult struct {
+	// The call requested by the model.
+	Call ToolCall
+
+	// The content of the tool message sent back to the model.
+	Output string
+
+	// The error returned by the tool, if any. When set, Output
+	// contains the error message reported to the model.
+	Err error
+
+	// How long the tool took to run.
+	Duration time.Duration
+}
+
+// A single iteration of a Run: one response from the model,
+// and the results of the tools it called.
+type RunStep struct {
+	Response    *Response
+	ToolResults []ToolResult
+}
+
+// The result of Runner.Run.
+type RunResult struct {
+	// The final response from the model. If Run failed, this
+	// is the last response received, and may be nil.
+	Response *Response
+
+	// The complete conversation, including the messages in the original
+	// request, every assistant message and every tool message.
+	Messages []Chat
+
+	// A transcript of every iteration, in order.
+	Steps []RunStep
+}
+
+// Run sends request to the model, then runs the tool loop until the model
+// responds without calling a tool. If request.Tools is empty, the tools in
+// the Registry are sent with the request. Only the first choice of each
+// response is used.
+//
+// The returned RunResult is never nil, and contains the transcript up
+// to the point of failure if an error is returned.
+func (r *Runner) Run(ctx context.Context, request *Request) (*RunResult, error) {
+	result := &RunResult{}
+	if request == nil {
+		return result, errors.New("nil request provided")
+	}
+	if r.Registry == nil {
+		return result, errors.New("runner has no tool registry")
+	}
+
+	maxIterations := r.MaxIterations
+	if maxIterations <= 0 {
+		maxIterations = DefaultMaxIterations
+	}
+
+	req := *request
+	if len(req.Tools) == 0 {
+		req.Tools = r.Registry.Tools()
+	}
+	result.Messages = append([]Chat{}, request.Messages...)
+
+	for i := 0; i < maxIterations; i++ {
+		req.Messages = result.Messages
+		resp, err := MakeRequestWithClientContext(ctx, r.Client, &req, r.OrganizationID)
+		if resp != nil {
+			result.Response = resp
+		}
+		if err != nil {
+			return result, err
+		}
+
+		message := resp.Choices[0].Message
+		result.Messages = append(result.Messages, message)
+		step := RunStep{Response: resp}
+		if len(message.ToolCalls) == 0 {
+			result.Steps = append(result.Steps, step)
+			return result, nil
+		}
+
+		step.ToolResults = r.callTools(ctx, message.ToolCalls)
+		result.Steps = append(result.Steps, step)
+		if err = ctx.Err(); err != nil {
+			return result, err
+		}
+		for _, tr := range step.ToolResults {
+			result.Messages = append(result.Messages, NewToolMessage(tr.Call.ID, tr.Output))
+		}
+	}
+	return result, ErrMaxIterations
+}
+
+func (r *Runner) callTools(ctx context.Context, calls []ToolCall) []ToolResult {
+	results := make([]ToolResult, len(calls))
+	var sem chan struct{}
+	if r.MaxParallelToolCalls > 0 {
+		sem = make(chan struct{}, r.MaxParallelToolCalls)
+	}
+
+	wg := sync.WaitGroup{}
+	for i := range calls {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if sem != nil {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+			}
+
+			start := time.Now()
+			output, err := r.Registry.Call(ctx, calls[i])
+			if err != nil {
+				output = "error: " + err.Error()
+			}
+			results[i] = ToolResult{
+				Call:     calls[i],
+				Output:   output,
+				Err:      err,
+				Duration: time.Since(start),
+			}
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,130 @@
+package chat_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/common"
+)
+
+type addArgs struct {
+	A int `json:"a"`
+	B int `json:"b"`
+}
+
+type addResult struct {
+	Sum int `json:"sum"`
+}
+
+func newTestRegistry(t *testing.T) *chat.ToolRegistry {
+	registry := chat.NewToolRegistry()
+	err := chat.RegisterTool(registry, chat.FunctionDefinition{Name: "add"}, func(ctx context.Context, args addArgs) (addResult, error) {
+		return addResult{Sum: args.A + args.B}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = chat.RegisterTool(registry, chat.FunctionDefinition{Name: "fail"}, func(ctx context.Context, args struct{}) (string, error) {
+		return "", errors.New("something went wrong")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = chat.RegisterTool(registry, chat.FunctionDefinition{Name: "add"}, func(ctx context.Context, args addArgs) (string, error) {
+		return "", nil
+	}); err == nil {
+		t.Fatal("expected duplicate registration to fail")
+	}
+	return registry
+}
+
+func TestRunner(t *testing.T) {
+	requests := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := chat.Request{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if len(req.Tools) != 2 {
+			t.Errorf("expected 2 tools, got %d", len(req.Tools))
+		}
+
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Write([]byte(`{"choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","content":"","tool_calls":[
+				{"id":"call_1","type":"function","function":{"name":"add","arguments":"{\"a\":1,\"b\":2}"}},
+				{"id":"call_2","type":"function","function":{"name":"fail","arguments":"{}"}},
+				{"id":"call_3","type":"function","function":{"name":"missing","arguments":"{}"}}]}}]}`))
+			return
+		}
+
+		results := map[string]string{}
+		for _, m := range req.Messages {
+			if m.Role == chat.ToolRole {
+				results[m.ToolCallID] = m.Content
+			}
+		}
+		if results["call_1"] != `{"sum":3}` ||
+			results["call_2"] != "error: something went wrong" ||
+			!strings.Contains(results["call_3"], `unknown tool "missing"`) {
+			t.Errorf("unexpected tool results: %v", results)
+		}
+		w.Write([]byte(`{"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"1 + 2 = 3"}}]}`))
+	}))
+	defer server.Close()
+
+	runner := chat.Runner{
+		Registry: newTestRegistry(t),
+		Client:   common.NewClient(common.WithAPIKey("key"), common.WithBaseURL(server.URL+"/v1")),
+	}
+	result, err := runner.Run(context.Background(), &chat.Request{
+		Model:    "gpt-4o",
+		Messages: []chat.Chat{{Role: chat.UserRole, Content: "What is 1 + 2?"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Response.Choices[0].Message.Content != "1 + 2 = 3" {
+		t.Fatalf("unexpected final response: %+v", result.Response)
+	}
+	if len(result.Messages) != 6 {
+		t.Fatalf("expected 6 messages, got %d", len(result.Messages))
+	}
+	if len(result.Steps) != 2 || len(result.Steps[0].ToolResults) != 3 {
+		t.Fatalf("unexpected transcript: %+v", result.Steps)
+	}
+	if result.Steps[0].ToolResults[0].Err != nil || result.Steps[0].ToolResults[1].Err == nil {
+		t.Fatalf("unexpected tool errors: %+v", result.Steps[0].ToolResults)
+	}
+}
+
+func TestRunnerMaxIterations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","content":"","tool_calls":[
+			{"id":"call","type":"function","function":{"name":"add","arguments":"{\"a\":1,\"b\":1}"}}]}}]}`))
+	}))
+	defer server.Close()
+
+	runner := chat.Runner{
+		Registry:      newTestRegistry(t),
+		Client:        common.NewClient(common.WithAPIKey("key"), common.WithBaseURL(server.URL+"/v1")),
+		MaxIterations: 3,
+	}
+	result, err := runner.Run(context.Background(), &chat.Request{
+		Model:    "gpt-4o",
+		Messages: []chat.Chat{{Role: chat.UserRole, Content: "Loop forever."}},
+	})
+	if !errors.Is(err, chat.ErrMaxIterations) {
+		t.Fatalf("expected ErrMaxIterations, got %v", err)
+	}
+	if len(result.Steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d", len(result.Steps))
+	}
+}
@@ -0,0 +1,211 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Kardbord/gopenai/common"
+)
+
+// See common.StreamOptions.
+type StreamOptions = common.StreamOptions
+
+// A fragment of a tool call streamed in a Delta. The first fragment
+// of each tool call carries its ID, type and function name; subsequent
+// fragments with the same Index carry pieces of the function arguments.
+type ToolCallDelta struct {
+	Index    int64        `json:"index"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// The portion of a chat completion message streamed in a single chunk.
+type Delta struct {
+	Role      Role            `json:"role,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	Refusal   string          `json:"refusal,omitempty"`
+	ToolCalls []ToolCallDelta `json:"tool_calls,omitempty"`
+}
+
+type StreamChoice struct {
+	Index        int64     `json:"index"`
+	Delta        Delta     `json:"delta"`
+	FinishReason string    `json:"finish_reason,omitempty"`
+	LogProbs     *LogProbs `json:"logprobs,omitempty"`
+
+	// Only set by the Azure OpenAI service.
+	ContentFilterResults *ContentFilterResults `json:"content_filter_results,omitempty"`
+}
+
+// A chunk of a streamed chat completion response.
+type StreamChunk struct {
+	ID                string         `json:"id,omitempty"`
+	Choices           []StreamChoice `json:"choices"`
+	Created           int64          `json:"created,omitempty"`
+	Model             string         `json:"model,omitempty"`
+	SystemFingerprint string         `json:"system_fingerprint,omitempty"`
+	Object            string         `json:"object,omitempty"`
+
+	// Only set on the final chunk, when StreamOptions.IncludeUsage is set.
+	Usage *common.ResponseUsage `json:"usage,omitempty"`
+
+	// Only set by the Azure OpenAI service, usually on the first chunk.
+	PromptFilterResults []PromptFilterResult `json:"prompt_filter_results,omitempty"`
+}
+
+// A stream of chat completion chunks. See common.Stream.
+type Stream = common.Stream[StreamChunk]
+
+// Make a chat completion request, streaming the response as it is generated.
+// request.Stream is set automatically. The caller must close the returned stream.
+//
+//	stream, err := chat.MakeStreamingRequest(request, nil)
+//	if err != nil {
+//		return err
+//	}
+//	defer stream.Close()
+//	for stream.Next() {
+//		for _, choice := range stream.Current().Choices {
+//			fmt.Print(choice.Delta.Content)
+//		}
+//	}
+//	return stream.Err()
+func MakeStreamingRequest(request *Request, organizationID *string) (*Stream, error) {
+	return MakeStreamingRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeStreamingRequest, except the request is made with the given context.
+func MakeStreamingRequestContext(ctx context.Context, request *Request, organizationID *string) (*Stream, error) {
+	return MakeStreamingRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeStreamingRequest, except the request is sent using the given client.
+func MakeStreamingRequestWithClient(client *common.Client, request *Request, organizationID *string) (*Stream, error) {
+	return MakeStreamingRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeStreamingRequestWithClient, except the request is made with the given context.
+func MakeStreamingRequestWithClientContext(ctx context.Context, client *common.Client, request *Request, organizationID *string) (*Stream, error) {
+	if request == nil {
+		return nil, errors.New("nil request provided")
+	}
+	streamRequest := *request
+	streamRequest.Stream = true
+	return common.MakeStreamingRequestWithClientContext[Request, StreamChunk](ctx, client, &streamRequest, Endpoint, http.MethodPost, organizationID)
+}
+
+// An Accumulator reassembles the chunks of a streamed chat completion
+// into a complete Response.
+type Accumulator struct {
+	response Response
+}
+
+// Add merges chunk into the accumulated response. An error is returned
+// if a tool call in chunk has an index which does not follow on from
+// those already accumulated, in which case the rest of chunk is ignored.
+func (a *Accumulator) Add(chunk *StreamChunk) error {
+	if chunk == nil {
+		return nil
+	}
+	r := &a.response
+	if len(chunk.ID) != 0 {
+		r.ID = chunk.ID
+	}
+	if chunk.Created != 0 {
+		r.Created = chunk.Created
+	}
+	if len(chunk.Model) != 0 {
+		r.Model = chunk.Model
+	}
+	if len(chunk.SystemFingerprint) != 0 {
+		r.SystemFingerprint = chunk.SystemFingerprint
+	}
+	r.Object = "chat.completion"
+	if chunk.Usage != nil {
+		r.Usage = *chunk.Usage
+	}
+	r.PromptFilterResults = append(r.PromptFilterResults, chunk.PromptFilterResults...)
+
+	for _, sc := range chunk.Choices {
+		choice := a.choice(sc.Index)
+		if len(sc.Delta.Role) != 0 {
+			choice.Message.Role = sc.Delta.Role
+		}
+		choice.Message.Content += sc.Delta.Content
+		choice.Message.Refusal += sc.Delta.Refusal
+		if len(sc.FinishReason) != 0 {
+			choice.FinishReason = sc.FinishReason
+		}
+		if sc.LogProbs != nil {
+			if choice.LogProbs == nil {
+				choice.LogProbs = &LogProbs{}
+			}
+			choice.LogProbs.Content = append(choice.LogProbs.Content, sc.LogProbs.Content...)
+			choice.LogProbs.Refusal = append(choice.LogProbs.Refusal, sc.LogProbs.Refusal...)
+		}
+		if sc.ContentFilterResults != nil {
+			// Azure filters a stream in segments, reporting the results
+			// of each. The latest are kept, unless a segment was filtered.
+			if choice.ContentFilterResults == nil || !choice.ContentFilterResults.Filtered() {
+				choice.ContentFilterResults = sc.ContentFilterResults
+			}
+		}
+
+		for _, tc := range sc.Delta.ToolCalls {
+			// Tool calls are streamed in order, so an index may at most
+			// start the next call.
+			if tc.Index < 0 || tc.Index > int64(len(choice.Message.ToolCalls)) {
+				return fmt.Errorf("invalid tool call index %d in streamed choice %d", tc.Index, sc.Index)
+			}
+			if tc.Index == int64(len(choice.Message.ToolCalls)) {
+				choice.Message.ToolCalls = append(choice.Message.ToolCalls, ToolCall{})
+			}
+			call := &choice.Message.ToolCalls[tc.Index]
+			if len(tc.ID) != 0 {
+				call.ID = tc.ID
+			}
+			if len(tc.Type) != 0 {
+				call.Type = tc.Type
+			}
+			if len(tc.Function.Name) != 0 {
+				call.Function.Name = tc.Function.Name
+			}
+			call.Function.Arguments += tc.Function.Arguments
+		}
+	}
+	return nil
+}
+
+func (a *Accumulator) choice(index int64) *Choice {
+	for i := range a.response.Choices {
+		if a.response.Choices[i].Index == index {
+			return &a.response.Choices[i]
+		}
+	}
+	a.response.Choices = append(a.response.Choices, Choice{Index: index})
+	return &a.response.Choices[len(a.response.Choices)-1]
+}
+
+// Response returns the response accumulated so far.
+func (a *Accumulator) Response() *Response {
+	r := a.response
+	return &r
+}
+
+// Accumulate reads the remainder of stream, returning the complete
+// response. The stream is not closed.
+func Accumulate(stream *Stream) (*Response, error) {
+	acc := Accumulator{}
+	for stream.Next() {
+		if err := acc.Add(stream.Current()); err != nil {
+			return acc.Response(), err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return acc.Response(), err
+	}
+	return acc.Response(), nil
+}
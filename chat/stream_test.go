@@ -0,0 +1,83 @@
+package chat_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/common"
+)
+
+var streamedChunks = []string{
+	`{"id":"chatcmpl-1","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+	`{"id":"chatcmpl-1","model":"gpt-4o","choices":[{"index":0,"delta":{"content":"lo!"}}]}`,
+	`{"id":"chatcmpl-1","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{\"q\":"}}]}}]}`,
+	`{"id":"chatcmpl-1","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"otters\"}"}}]}}]}`,
+	`{"id":"chatcmpl-1","model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+	`{"id":"chatcmpl-1","model":"gpt-4o","choices":[],"usage":{"prompt_tokens":9,"completion_tokens":12,"total_tokens":21}}`,
+}
+
+func TestStreamingRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chat.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Error("expected stream and stream_options.include_usage to be set")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range streamedChunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := common.NewClient(common.WithAPIKey("key"), common.WithBaseURL(server.URL+"/v1"))
+	stream, err := chat.MakeStreamingRequestWithClient(client, &chat.Request{
+		Model:         "gpt-4o",
+		Messages:      []chat.Chat{{Role: chat.UserRole, Content: "Hello!"}},
+		StreamOptions: &chat.StreamOptions{IncludeUsage: true},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	resp, err := chat.Accumulate(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Choices) != 1 {
+		t.Fatalf("expected 1 choice, got %d", len(resp.Choices))
+	}
+	msg := resp.Choices[0].Message
+	if msg.Role != chat.AssistantRole || msg.Content != "Hello!" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	if resp.Choices[0].FinishReason != "tool_calls" {
+		t.Fatalf("unexpected finish reason: %s", resp.Choices[0].FinishReason)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].ID != "call_1" || msg.ToolCalls[0].Function.Arguments != `{"q":"otters"}` {
+		t.Fatalf("unexpected tool calls: %+v", msg.ToolCalls)
+	}
+	if resp.Usage.TotalTokens != 21 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestAccumulatorInvalidToolCallIndex(t *testing.T) {
+	for _, index := range []int64{-1, 1, 1 << 40} {
+		acc := chat.Accumulator{}
+		err := acc.Add(&chat.StreamChunk{Choices: []chat.StreamChoice{{
+			Delta: chat.Delta{ToolCalls: []chat.ToolCallDelta{{Index: index}}},
+		}}})
+		if err == nil {
+			t.Fatalf("expected an error for tool call index %d", index)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/Kardbord/gopenai/common"
+)
+
+// A RefusalError is returned by MakeStructuredRequest when the model
+// refuses to respond, for example for safety reasons. A refusal does not
+// follow the requested schema, so there is no value to decode.
+type RefusalError struct {
+	// The refusal message generated by the model.
+	Refusal string
+
+	// The response containing the refusal.
+	Response *Response
+}
+
+func (e *RefusalError) Error() string {
+	return "model refused to respond: " + e.Refusal
+}
+
+// Make a chat completion request using Structured Outputs, and decode
+// the model's response into a T. If request.ResponseFormat is not a
+// json_schema format, it is set to one generated from T with
+// NewJSONSchemaResponseFormat, named after T.
+//
+// If the model refuses to respond, a *RefusalError is returned. Only
+// the first choice of the response is decoded. The response is returned
+// whenever one was received, even if an error is also returned.
+func MakeStructuredRequest[T any](request *Request, organizationID *string) (*T, *Response, error) {
+	return MakeStructuredRequestWithClientContext[T](context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeStructuredRequest, except the request is made with the given context.
+func MakeStructuredRequestContext[T any](ctx context.Context, request *Request, organizationID *string) (*T, *Response, error) {
+	return MakeStructuredRequestWithClientContext[T](ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeStructuredRequest, except the request is sent using the given client.
+func MakeStructuredRequestWithClient[T any](client *common.Client, request *Request, organizationID *string) (*T, *Response, error) {
+	return MakeStructuredRequestWithClientContext[T](context.Background(), client, request, organizationID)
+}
+
+// Same as MakeStructuredRequestWithClient, except the request is made with the given context.
+func MakeStructuredRequestWithClientContext[T any](ctx context.Context, client *common.Client, request *Request, organizationID *string) (*T, *Response, error) {
+	if request == nil {
+		return nil, nil, errors.New("nil request provided")
+	}
+	structuredRequest := *request
+	if structuredRequest.ResponseFormat == nil || structuredRequest.ResponseFormat.Type != ResponseFormatJSONSchema {
+		format, err := NewJSONSchemaResponseFormat[T](schemaName[T]())
+		if err != nil {
+			return nil, nil, err
+		}
+		structuredRequest.ResponseFormat = format
+	}
+
+	r, err := MakeRequestWithClientContext(ctx, client, &structuredRequest, organizationID)
+	if err != nil {
+		return nil, r, err
+	}
+
+	choice := r.Choices[0]
+	if len(choice.Message.Refusal) != 0 {
+		return nil, r, &RefusalError{Refusal: choice.Message.Refusal, Response: r}
+	}
+
+	value := new(T)
+	if err = json.Unmarshal([]byte(choice.Message.Content), value); err != nil {
+		if choice.FinishReason == "length" {
+			return nil, r, fmt.Errorf("response was truncated by the token limit: %w", err)
+		}
+		return nil, r, fmt.Errorf("unable to decode response: %w", err)
+	}
+	return value, r, nil
+}
+
+var invalidSchemaNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// schemaName derives a valid response format name from the name of T.
+func schemaName[T any]() string {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	name := invalidSchemaNameChars.ReplaceAllString(t.Name(), "_")
+	if len(name) == 0 {
+		return "response"
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
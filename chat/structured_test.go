@@ -0,0 +1,68 @@
+package chat_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/common"
+)
+
+type CalendarEvent struct {
+	Name         string   `json:"name"`
+	Date         string   `json:"date"`
+	Participants []string `json:"participants"`
+}
+
+func TestStructuredRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := map[string]any{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		format := req["response_format"].(map[string]any)
+		schema := format["json_schema"].(map[string]any)
+		if format["type"] != "json_schema" || schema["name"] != "CalendarEvent" || schema["strict"] != true {
+			t.Errorf("unexpected response format: %v", format)
+		}
+
+		w.Write([]byte(`{"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"{\"name\":\"Science fair\",\"date\":\"Friday\",\"participants\":[\"Alice\",\"Bob\"]}"}}]}`))
+	}))
+	defer server.Close()
+
+	client := common.NewClient(common.WithAPIKey("key"), common.WithBaseURL(server.URL+"/v1"))
+	event, resp, err := chat.MakeStructuredRequestWithClient[CalendarEvent](client, &chat.Request{
+		Model:    "gpt-4o",
+		Messages: []chat.Chat{{Role: chat.UserRole, Content: "Alice and Bob are going to a science fair on Friday."}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || event.Name != "Science fair" || len(event.Participants) != 2 {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+}
+
+func TestStructuredRequestRefusal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":null,"refusal":"I'm sorry, I cannot assist with that request."}}]}`))
+	}))
+	defer server.Close()
+
+	client := common.NewClient(common.WithAPIKey("key"), common.WithBaseURL(server.URL+"/v1"))
+	event, resp, err := chat.MakeStructuredRequestWithClient[CalendarEvent](client, &chat.Request{
+		Model:    "gpt-4o",
+		Messages: []chat.Chat{{Role: chat.UserRole, Content: "Something objectionable."}},
+	}, nil)
+
+	refusal := new(chat.RefusalError)
+	if !errors.As(err, &refusal) {
+		t.Fatalf("expected a RefusalError, got %v", err)
+	}
+	if event != nil || resp == nil || refusal.Refusal != "I'm sorry, I cannot assist with that request." {
+		t.Fatalf("unexpected refusal: %+v", refusal)
+	}
+}
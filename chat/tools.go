@@ -0,0 +1,131 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/Kardbord/gopenai/jsonschema"
+)
+
+const ToolTypeFunction = "function"
+
+// The definition of a function the model may call.
+type FunctionDefinition struct {
+	// The name of the function to be called. Must be a-z, A-Z, 0-9, or
+	// contain underscores and dashes, with a maximum length of 64.
+	Name string `json:"name"`
+
+	// A description of what the function does, used by the model to
+	// choose when and how to call the function.
+	Description string `json:"description,omitempty"`
+
+	// The parameters the function accepts, described as a JSON Schema
+	// object. Any value which marshals to a JSON Schema may be used, such
+	// as a json.RawMessage or a map[string]any.
+	//
+	// Omitting parameters defines a function with an empty parameter list.
+	// See NewFunctionDefinition to generate parameters from a Go type.
+	Parameters any `json:"parameters,omitempty"`
+
+	// Whether to enable strict schema adherence when generating the function
+	// call. If set to true, the model will follow the exact schema defined in
+	// the parameters field. Only a subset of JSON Schema is supported when
+	// strict is true.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// A tool the model may call.
+type Tool struct {
+	// The type of the tool. Currently, only ToolTypeFunction is supported.
+	Type string `json:"type"`
+
+	Function FunctionDefinition `json:"function"`
+}
+
+// NewFunctionTool creates a Tool for the given function definition.
+func NewFunctionTool(function FunctionDefinition) Tool {
+	return Tool{
+		Type:     ToolTypeFunction,
+		Function: function,
+	}
+}
+
+// NewFunctionDefinition creates a strict FunctionDefinition whose
+// parameters are described by a JSON Schema generated from Args,
+// as described by the jsonschema package.
+func NewFunctionDefinition[Args any](name, description string) (FunctionDefinition, error) {
+	schema, err := jsonschema.Generate[Args]()
+	if err != nil {
+		return FunctionDefinition{}, err
+	}
+	return FunctionDefinition{
+		Name:        name,
+		Description: description,
+		Parameters:  schema,
+		Strict:      true,
+	}, nil
+}
+
+// Controls which (if any) tool is called by the model.
+// See Request.ToolChoice.
+type ToolChoice struct {
+	// One of "none", "auto" or "required".
+	// Ignored if Function is set.
+	Mode string
+
+	// The name of a function the model is forced to call.
+	Function string
+}
+
+var (
+	ToolChoiceNone     = &ToolChoice{Mode: "none"}
+	ToolChoiceAuto     = &ToolChoice{Mode: "auto"}
+	ToolChoiceRequired = &ToolChoice{Mode: "required"}
+)
+
+// ToolChoiceFunction forces the model to call the named function.
+func ToolChoiceFunction(name string) *ToolChoice {
+	return &ToolChoice{Function: name}
+}
+
+type namedToolChoice struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+func (t ToolChoice) MarshalJSON() ([]byte, error) {
+	if len(t.Function) == 0 {
+		return json.Marshal(t.Mode)
+	}
+	named := namedToolChoice{Type: ToolTypeFunction}
+	named.Function.Name = t.Function
+	return json.Marshal(named)
+}
+
+func (t *ToolChoice) UnmarshalJSON(data []byte) error {
+	if len(data) != 0 && data[0] == '"' {
+		*t = ToolChoice{}
+		return json.Unmarshal(data, &t.Mode)
+	}
+	named := namedToolChoice{}
+	if err := json.Unmarshal(data, &named); err != nil {
+		return err
+	}
+	if len(named.Function.Name) == 0 {
+		return errors.New("tool choice is missing a function name")
+	}
+	*t = ToolChoice{Function: named.Function.Name}
+	return nil
+}
+
+// NewToolMessage creates a ToolRole message containing the result of
+// the tool call with the given ID, to send back to the model.
+func NewToolMessage(toolCallID, content string) Chat {
+	return Chat{
+		Role:       ToolRole,
+		ToolCallID: toolCallID,
+		Content:    content,
+	}
+}
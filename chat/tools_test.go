@@ -0,0 +1,89 @@
+package chat_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/common"
+)
+
+func TestToolChoiceJSON(t *testing.T) {
+	for expected, choice := range map[string]*chat.ToolChoice{
+		`"none"`:     chat.ToolChoiceNone,
+		`"auto"`:     chat.ToolChoiceAuto,
+		`"required"`: chat.ToolChoiceRequired,
+		`{"type":"function","function":{"name":"get_weather"}}`: chat.ToolChoiceFunction("get_weather"),
+	} {
+		data, err := json.Marshal(choice)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Fatalf("expected %s, got %s", expected, data)
+		}
+
+		decoded := chat.ToolChoice{}
+		if err = json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded != *choice {
+			t.Fatalf("expected %+v, got %+v", *choice, decoded)
+		}
+	}
+}
+
+func TestToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]any
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Error(err)
+		}
+		if req["parallel_tool_calls"] != false || req["tool_choice"] != "required" {
+			t.Errorf("unexpected request: %s", body)
+		}
+		tools := req["tools"].([]any)
+		function := tools[0].(map[string]any)["function"].(map[string]any)
+		if function["name"] != "get_weather" || function["strict"] != true {
+			t.Errorf("unexpected tool: %v", function)
+		}
+
+		w.Write([]byte(`{"choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]}}]}`))
+	}))
+	defer server.Close()
+
+	parallel := false
+	client := common.NewClient(common.WithAPIKey("key"), common.WithBaseURL(server.URL+"/v1"))
+	resp, err := chat.MakeRequestWithClient(client, &chat.Request{
+		Model:    "gpt-4o",
+		Messages: []chat.Chat{{Role: chat.UserRole, Content: "What is the weather in Paris?"}},
+		Tools: []chat.Tool{chat.NewFunctionTool(chat.FunctionDefinition{
+			Name:        "get_weather",
+			Description: "Get the current weather in a city.",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string"}},"required":["city"],"additionalProperties":false}`),
+			Strict:      true,
+		})},
+		ToolChoice:        chat.ToolChoiceRequired,
+		ParallelToolCalls: &parallel,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := resp.Choices[0].Message.ToolCalls
+	if len(calls) != 1 || calls[0].Function.Name != "get_weather" {
+		t.Fatalf("unexpected tool calls: %+v", calls)
+	}
+
+	data, err := json.Marshal(chat.NewToolMessage(calls[0].ID, "sunny"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"content":"sunny","role":"tool","tool_call_id":"call_1"}` {
+		t.Fatalf("unexpected tool message: %s", data)
+	}
+}
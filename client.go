@@ -0,0 +1,361 @@
+// Package gopenai provides a Client which exposes every endpoint
+// supported by this library as a method. Unlike the package-level
+// request functions, which share a single default configuration, each
+// Client carries its own API key, organization, project, base URL,
+// HTTP client and default headers.
+//
+//	client := gopenai.NewClient(
+//		common.WithAPIKey(key),
+//		common.WithOrganizationID(orgID),
+//	)
+//	resp, err := client.MakeChatRequest(&chat.Request{...}, nil)
+//
+// Every method has a Context variant, such as MakeChatRequestContext,
+// which binds the request to the given context.
+package gopenai
+
+import (
+	"context"
+
+	"github.com/Kardbord/gopenai/audio"
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/common"
+	"github.com/Kardbord/gopenai/completions"
+	"github.com/Kardbord/gopenai/embeddings"
+	"github.com/Kardbord/gopenai/files"
+	"github.com/Kardbord/gopenai/finetuning"
+	"github.com/Kardbord/gopenai/images"
+	"github.com/Kardbord/gopenai/models"
+	"github.com/Kardbord/gopenai/moderations"
+)
+
+// A Client sends requests to every OpenAI endpoint using its own
+// configuration. See common.Client for the available options.
+type Client struct {
+	*common.Client
+}
+
+// NewClient creates a Client configured with the given options.
+func NewClient(opts ...common.ClientOption) *Client {
+	return &Client{Client: common.NewClient(opts...)}
+}
+
+// See chat.MakeRequest.
+func (c *Client) MakeChatRequest(request *chat.Request, organizationID *string) (*chat.Response, error) {
+	return chat.MakeRequestWithClient(c.Client, request, organizationID)
+}
+
+// See chat.MakeRequestContext.
+func (c *Client) MakeChatRequestContext(ctx context.Context, request *chat.Request, organizationID *string) (*chat.Response, error) {
+	return chat.MakeRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See chat.MakeModeratedRequest.
+func (c *Client) MakeModeratedChatRequest(request *chat.Request, organizationID *string) (*chat.Response, *moderations.Response, error) {
+	return chat.MakeModeratedRequestWithClient(c.Client, request, organizationID)
+}
+
+// See chat.MakeModeratedRequestContext.
+func (c *Client) MakeModeratedChatRequestContext(ctx context.Context, request *chat.Request, organizationID *string) (*chat.Response, *moderations.Response, error) {
+	return chat.MakeModeratedRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See chat.MakeStreamingRequest.
+func (c *Client) MakeChatStreamingRequest(request *chat.Request, organizationID *string) (*chat.Stream, error) {
+	return chat.MakeStreamingRequestWithClient(c.Client, request, organizationID)
+}
+
+// See chat.MakeStreamingRequestContext.
+func (c *Client) MakeChatStreamingRequestContext(ctx context.Context, request *chat.Request, organizationID *string) (*chat.Stream, error) {
+	return chat.MakeStreamingRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See completions.MakeRequest.
+func (c *Client) MakeCompletionsRequest(request *completions.Request, organizationID *string) (*completions.Response, error) {
+	return completions.MakeRequestWithClient(c.Client, request, organizationID)
+}
+
+// See completions.MakeRequestContext.
+func (c *Client) MakeCompletionsRequestContext(ctx context.Context, request *completions.Request, organizationID *string) (*completions.Response, error) {
+	return completions.MakeRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See completions.MakeModeratedRequest.
+func (c *Client) MakeModeratedCompletionsRequest(request *completions.Request, organizationID *string) (*completions.Response, *moderations.Response, error) {
+	return completions.MakeModeratedRequestWithClient(c.Client, request, organizationID)
+}
+
+// See completions.MakeModeratedRequestContext.
+func (c *Client) MakeModeratedCompletionsRequestContext(ctx context.Context, request *completions.Request, organizationID *string) (*completions.Response, *moderations.Response, error) {
+	return completions.MakeModeratedRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See completions.MakeStreamingRequest.
+func (c *Client) MakeCompletionsStreamingRequest(request *completions.Request, organizationID *string) (*completions.Stream, error) {
+	return completions.MakeStreamingRequestWithClient(c.Client, request, organizationID)
+}
+
+// See completions.MakeStreamingRequestContext.
+func (c *Client) MakeCompletionsStreamingRequestContext(ctx context.Context, request *completions.Request, organizationID *string) (*completions.Stream, error) {
+	return completions.MakeStreamingRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See embeddings.MakeRequest.
+func (c *Client) MakeEmbeddingsRequest(request *embeddings.Request, organizationID *string) (*embeddings.Response, error) {
+	return embeddings.MakeRequestWithClient(c.Client, request, organizationID)
+}
+
+// See embeddings.MakeRequestContext.
+func (c *Client) MakeEmbeddingsRequestContext(ctx context.Context, request *embeddings.Request, organizationID *string) (*embeddings.Response, error) {
+	return embeddings.MakeRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See embeddings.MakeModeratedRequest.
+func (c *Client) MakeModeratedEmbeddingsRequest(request *embeddings.Request, organizationID *string) (*embeddings.Response, *moderations.Response, error) {
+	return embeddings.MakeModeratedRequestWithClient(c.Client, request, organizationID)
+}
+
+// See embeddings.MakeModeratedRequestContext.
+func (c *Client) MakeModeratedEmbeddingsRequestContext(ctx context.Context, request *embeddings.Request, organizationID *string) (*embeddings.Response, *moderations.Response, error) {
+	return embeddings.MakeModeratedRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See files.MakeListRequest.
+func (c *Client) MakeFilesListRequest(organizationID *string) (*files.ListResponse, error) {
+	return files.MakeListRequestWithClient(c.Client, organizationID)
+}
+
+// See files.MakeListRequestContext.
+func (c *Client) MakeFilesListRequestContext(ctx context.Context, organizationID *string) (*files.ListResponse, error) {
+	return files.MakeListRequestWithClientContext(ctx, c.Client, organizationID)
+}
+
+// See files.MakeUploadRequest.
+func (c *Client) MakeFilesUploadRequest(request *files.UploadRequest, organizationID *string) (*files.UploadedFile, error) {
+	return files.MakeUploadRequestWithClient(c.Client, request, organizationID)
+}
+
+// See files.MakeUploadRequestContext.
+func (c *Client) MakeFilesUploadRequestContext(ctx context.Context, request *files.UploadRequest, organizationID *string) (*files.UploadedFile, error) {
+	return files.MakeUploadRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See files.MakeDeleteRequest.
+func (c *Client) MakeFilesDeleteRequest(fileID string, organizationID *string) (*files.DeleteResponse, error) {
+	return files.MakeDeleteRequestWithClient(c.Client, fileID, organizationID)
+}
+
+// See files.MakeDeleteRequestContext.
+func (c *Client) MakeFilesDeleteRequestContext(ctx context.Context, fileID string, organizationID *string) (*files.DeleteResponse, error) {
+	return files.MakeDeleteRequestWithClientContext(ctx, c.Client, fileID, organizationID)
+}
+
+// See files.MakeRetrieveRequest.
+func (c *Client) MakeFilesRetrieveRequest(fileID string, organizationID *string) (*files.UploadedFile, error) {
+	return files.MakeRetrieveRequestWithClient(c.Client, fileID, organizationID)
+}
+
+// See files.MakeRetrieveRequestContext.
+func (c *Client) MakeFilesRetrieveRequestContext(ctx context.Context, fileID string, organizationID *string) (*files.UploadedFile, error) {
+	return files.MakeRetrieveRequestWithClientContext(ctx, c.Client, fileID, organizationID)
+}
+
+// See files.MakeRetrieveContentRequest.
+func (c *Client) MakeFilesRetrieveContentRequest(fileID, filepath string, overwrite bool, organizationID *string) error {
+	return files.MakeRetrieveContentRequestWithClient(c.Client, fileID, filepath, overwrite, organizationID)
+}
+
+// See files.MakeRetrieveContentRequestContext.
+func (c *Client) MakeFilesRetrieveContentRequestContext(ctx context.Context, fileID, filepath string, overwrite bool, organizationID *string) error {
+	return files.MakeRetrieveContentRequestWithClientContext(ctx, c.Client, fileID, filepath, overwrite, organizationID)
+}
+
+// See files.MakeRetrieveContentRequestNoDisk.
+func (c *Client) MakeFilesRetrieveContentRequestNoDisk(fileID string, organizationID *string) ([]byte, error) {
+	return files.MakeRetrieveContentRequestNoDiskWithClient(c.Client, fileID, organizationID)
+}
+
+// See files.MakeRetrieveContentRequestNoDiskContext.
+func (c *Client) MakeFilesRetrieveContentRequestNoDiskContext(ctx context.Context, fileID string, organizationID *string) ([]byte, error) {
+	return files.MakeRetrieveContentRequestNoDiskWithClientContext(ctx, c.Client, fileID, organizationID)
+}
+
+// See finetuning.MakeCreationRequest.
+func (c *Client) MakeFineTuningCreationRequest(request *finetuning.CreationRequest, organizationID *string) (*finetuning.FineTune, error) {
+	return finetuning.MakeCreationRequestWithClient(c.Client, request, organizationID)
+}
+
+// See finetuning.MakeCreationRequestContext.
+func (c *Client) MakeFineTuningCreationRequestContext(ctx context.Context, request *finetuning.CreationRequest, organizationID *string) (*finetuning.FineTune, error) {
+	return finetuning.MakeCreationRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See finetuning.MakeListRequest.
+func (c *Client) MakeFineTuningListRequest(limit *uint64, after, organizationID *string) (*finetuning.ListResponse, error) {
+	return finetuning.MakeListRequestWithClient(c.Client, limit, after, organizationID)
+}
+
+// See finetuning.MakeListRequestContext.
+func (c *Client) MakeFineTuningListRequestContext(ctx context.Context, limit *uint64, after, organizationID *string) (*finetuning.ListResponse, error) {
+	return finetuning.MakeListRequestWithClientContext(ctx, c.Client, limit, after, organizationID)
+}
+
+// See finetuning.MakeRetrieveRequest.
+func (c *Client) MakeFineTuningRetrieveRequest(fineTuneID string, organizationID *string) (*finetuning.FineTune, error) {
+	return finetuning.MakeRetrieveRequestWithClient(c.Client, fineTuneID, organizationID)
+}
+
+// See finetuning.MakeRetrieveRequestContext.
+func (c *Client) MakeFineTuningRetrieveRequestContext(ctx context.Context, fineTuneID string, organizationID *string) (*finetuning.FineTune, error) {
+	return finetuning.MakeRetrieveRequestWithClientContext(ctx, c.Client, fineTuneID, organizationID)
+}
+
+// See finetuning.MakeCancelRequest.
+func (c *Client) MakeFineTuningCancelRequest(fineTuneID string, organizationID *string) (*finetuning.FineTune, error) {
+	return finetuning.MakeCancelRequestWithClient(c.Client, fineTuneID, organizationID)
+}
+
+// See finetuning.MakeCancelRequestContext.
+func (c *Client) MakeFineTuningCancelRequestContext(ctx context.Context, fineTuneID string, organizationID *string) (*finetuning.FineTune, error) {
+	return finetuning.MakeCancelRequestWithClientContext(ctx, c.Client, fineTuneID, organizationID)
+}
+
+// See finetuning.MakeListEventsRequest.
+func (c *Client) MakeFineTuningListEventsRequest(fineTuneID string, limit *uint64, after, organizationID *string) (*finetuning.ListEventsResponse, error) {
+	return finetuning.MakeListEventsRequestWithClient(c.Client, fineTuneID, limit, after, organizationID)
+}
+
+// See finetuning.MakeListEventsRequestContext.
+func (c *Client) MakeFineTuningListEventsRequestContext(ctx context.Context, fineTuneID string, limit *uint64, after, organizationID *string) (*finetuning.ListEventsResponse, error) {
+	return finetuning.MakeListEventsRequestWithClientContext(ctx, c.Client, fineTuneID, limit, after, organizationID)
+}
+
+// See finetuning.MakeDeleteRequest.
+func (c *Client) MakeFineTuningDeleteRequest(fineTuneModel string, organizationID *string) (*finetuning.DeleteResponse, error) {
+	return finetuning.MakeDeleteRequestWithClient(c.Client, fineTuneModel, organizationID)
+}
+
+// See finetuning.MakeDeleteRequestContext.
+func (c *Client) MakeFineTuningDeleteRequestContext(ctx context.Context, fineTuneModel string, organizationID *string) (*finetuning.DeleteResponse, error) {
+	return finetuning.MakeDeleteRequestWithClientContext(ctx, c.Client, fineTuneModel, organizationID)
+}
+
+// See images.MakeCreationRequest.
+func (c *Client) MakeImagesCreationRequest(request *images.CreationRequest, organizationID *string) (*images.Response, error) {
+	return images.MakeCreationRequestWithClient(c.Client, request, organizationID)
+}
+
+// See images.MakeCreationRequestContext.
+func (c *Client) MakeImagesCreationRequestContext(ctx context.Context, request *images.CreationRequest, organizationID *string) (*images.Response, error) {
+	return images.MakeCreationRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See images.MakeModeratedCreationRequest.
+func (c *Client) MakeModeratedImagesCreationRequest(request *images.CreationRequest, organizationID *string) (*images.Response, *moderations.Response, error) {
+	return images.MakeModeratedCreationRequestWithClient(c.Client, request, organizationID)
+}
+
+// See images.MakeModeratedCreationRequestContext.
+func (c *Client) MakeModeratedImagesCreationRequestContext(ctx context.Context, request *images.CreationRequest, organizationID *string) (*images.Response, *moderations.Response, error) {
+	return images.MakeModeratedCreationRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See images.MakeEditRequest.
+func (c *Client) MakeImagesEditRequest(request *images.EditRequest, organizationID *string) (*images.Response, error) {
+	return images.MakeEditRequestWithClient(c.Client, request, organizationID)
+}
+
+// See images.MakeEditRequestContext.
+func (c *Client) MakeImagesEditRequestContext(ctx context.Context, request *images.EditRequest, organizationID *string) (*images.Response, error) {
+	return images.MakeEditRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See images.MakeModeratedRequest.
+func (c *Client) MakeModeratedImagesEditRequest(request *images.EditRequest, organizationID *string) (*images.Response, *moderations.Response, error) {
+	return images.MakeModeratedRequestWithClient(c.Client, request, organizationID)
+}
+
+// See images.MakeModeratedRequestContext.
+func (c *Client) MakeModeratedImagesEditRequestContext(ctx context.Context, request *images.EditRequest, organizationID *string) (*images.Response, *moderations.Response, error) {
+	return images.MakeModeratedRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See images.MakeVariationRequest.
+func (c *Client) MakeImagesVariationRequest(request *images.VariationRequest, organizationID *string) (*images.Response, error) {
+	return images.MakeVariationRequestWithClient(c.Client, request, organizationID)
+}
+
+// See images.MakeVariationRequestContext.
+func (c *Client) MakeImagesVariationRequestContext(ctx context.Context, request *images.VariationRequest, organizationID *string) (*images.Response, error) {
+	return images.MakeVariationRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See audio.MakeTranscriptionRequest.
+func (c *Client) MakeAudioTranscriptionRequest(request *audio.TranscriptionRequest, organizationID *string) (*audio.Response, error) {
+	return audio.MakeTranscriptionRequestWithClient(c.Client, request, organizationID)
+}
+
+// See audio.MakeTranscriptionRequestContext.
+func (c *Client) MakeAudioTranscriptionRequestContext(ctx context.Context, request *audio.TranscriptionRequest, organizationID *string) (*audio.Response, error) {
+	return audio.MakeTranscriptionRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See audio.MakeTranslationRequest.
+func (c *Client) MakeAudioTranslationRequest(request *audio.TranslationRequest, organizationID *string) (*audio.Response, error) {
+	return audio.MakeTranslationRequestWithClient(c.Client, request, organizationID)
+}
+
+// See audio.MakeTranslationRequestContext.
+func (c *Client) MakeAudioTranslationRequestContext(ctx context.Context, request *audio.TranslationRequest, organizationID *string) (*audio.Response, error) {
+	return audio.MakeTranslationRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See audio.MakeSpeechRequest.
+func (c *Client) MakeAudioSpeechRequest(request *audio.SpeechRequest, organizationID *string) ([]byte, error) {
+	return audio.MakeSpeechRequestWithClient(c.Client, request, organizationID)
+}
+
+// See audio.MakeSpeechRequestContext.
+func (c *Client) MakeAudioSpeechRequestContext(ctx context.Context, request *audio.SpeechRequest, organizationID *string) ([]byte, error) {
+	return audio.MakeSpeechRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See models.MakeListModelsRequest.
+func (c *Client) MakeModelsListRequest(organizationID *string) (*models.ListModelsResponse, error) {
+	return models.MakeListModelsRequestWithClient(c.Client, organizationID)
+}
+
+// See models.MakeListModelsRequestContext.
+func (c *Client) MakeModelsListRequestContext(ctx context.Context, organizationID *string) (*models.ListModelsResponse, error) {
+	return models.MakeListModelsRequestWithClientContext(ctx, c.Client, organizationID)
+}
+
+// See models.MakeRetrieveModelRequest.
+func (c *Client) MakeModelsRetrieveRequest(model string, organizationID *string) (*models.ModelResponse, error) {
+	return models.MakeRetrieveModelRequestWithClient(c.Client, model, organizationID)
+}
+
+// See models.MakeRetrieveModelRequestContext.
+func (c *Client) MakeModelsRetrieveRequestContext(ctx context.Context, model string, organizationID *string) (*models.ModelResponse, error) {
+	return models.MakeRetrieveModelRequestWithClientContext(ctx, c.Client, model, organizationID)
+}
+
+// See moderations.MakeRequest.
+func (c *Client) MakeModerationsRequest(request *moderations.Request, organizationID *string) (*moderations.Response, error) {
+	return moderations.MakeRequestWithClient(c.Client, request, organizationID)
+}
+
+// See moderations.MakeRequestContext.
+func (c *Client) MakeModerationsRequestContext(ctx context.Context, request *moderations.Request, organizationID *string) (*moderations.Response, error) {
+	return moderations.MakeRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
+
+// See moderations.MakeModeratedRequest.
+func (c *Client) MakeModeratedModerationsRequest(request *moderations.Request, organizationID *string) (*moderations.Response, error) {
+	return moderations.MakeModeratedRequestWithClient(c.Client, request, organizationID)
+}
+
+// See moderations.MakeModeratedRequestContext.
+func (c *Client) MakeModeratedModerationsRequestContext(ctx context.Context, request *moderations.Request, organizationID *string) (*moderations.Response, error) {
+	return moderations.MakeModeratedRequestWithClientContext(ctx, c.Client, request, organizationID)
+}
@@ -0,0 +1,83 @@
+package gopenai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Kardbord/gopenai"
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/common"
+)
+
+func TestClientTenants(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{
+				"message": map[string]string{
+					"role":    chat.AssistantRole,
+					"content": r.Header.Get("Authorization") + " " + r.Header.Get("OpenAI-Organization"),
+				},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	tenants := map[string]*gopenai.Client{
+		"Bearer key-a org-a": gopenai.NewClient(
+			common.WithAPIKey("key-a"),
+			common.WithOrganizationID("org-a"),
+			common.WithBaseURL(server.URL+"/v1"),
+		),
+		"Bearer key-b org-b": gopenai.NewClient(
+			common.WithAPIKey("key-b"),
+			common.WithOrganizationID("org-b"),
+			common.WithBaseURL(server.URL+"/v1/"),
+			common.WithHTTPClient(server.Client()),
+		),
+	}
+
+	for expected, client := range tenants {
+		resp, err := client.MakeChatRequest(&chat.Request{
+			Model:    "gpt-3.5-turbo",
+			Messages: []chat.Chat{{Role: chat.UserRole, Content: "Hello!"}},
+		}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Choices[0].Message.Content != expected {
+			t.Fatalf("expected %q, got %q", expected, resp.Choices[0].Message.Content)
+		}
+	}
+}
+
+func TestClientContextCancellation(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client := gopenai.NewClient(common.WithAPIKey("key"), common.WithBaseURL(server.URL+"/v1"))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.MakeChatRequestContext(ctx, &chat.Request{
+		Model:    "gpt-3.5-turbo",
+		Messages: []chat.Chat{{Role: chat.UserRole, Content: "Hello!"}},
+	}, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,305 @@
+package common
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	auth "github.com/Kardbord/gopenai/authentication"
+)
+
+// The endpoints whose responses are cached by default, relative to BaseURL.
+var DefaultCachedEndpoints = []string{
+	"chat/completions",
+	"completions",
+	"embeddings",
+	"moderations",
+}
+
+// A Cache stores the responses to requests, by a key derived from the
+// request's method, URL and body. Responses to identical requests sent by
+// a Client configured WithCache are then served from the cache, without
+// sending the request.
+type Cache interface {
+	// Get returns the entry stored for key, if any and unexpired.
+	Get(key string) (*CacheEntry, bool)
+
+	// Set stores entry for key, replacing any existing entry.
+	Set(key string, entry *CacheEntry) error
+}
+
+// A CacheEntry is a response body stored in a Cache.
+type CacheEntry struct {
+	Body     []byte
+	StoredAt time.Time
+}
+
+// CacheInfo describes how a response relates to a Cache.
+type CacheInfo struct {
+	// The key of the request in the cache.
+	Key string
+
+	// Whether the response was served from the cache. Otherwise,
+	// the response was received from the API and then stored.
+	Hit bool
+
+	// When the response was stored in the cache.
+	StoredAt time.Time
+}
+
+// CacheMetadata is embedded in the responses of cacheable endpoints.
+type CacheMetadata struct {
+	// Set when the request was sent by a client configured WithCache,
+	// and not bypassed with ContextWithoutCache.
+	Cache *CacheInfo `json:"-"`
+}
+
+func (m *CacheMetadata) setCacheInfo(info *CacheInfo) {
+	m.Cache = info
+}
+
+type cacheInfoSetter interface {
+	setCacheInfo(info *CacheInfo)
+}
+
+// The endpoints which sample their responses, whose
+// requests are only cached when they are deterministic.
+var sampledEndpoints = map[string]bool{
+	"chat/completions": true,
+	"completions":      true,
+}
+
+// WithCache caches the successful responses to JSON requests sent to
+// the given endpoints, which may be relative to BaseURL, such as
+// "embeddings", or endpoint constants, such as embeddings.Endpoint.
+// If no endpoints are given, DefaultCachedEndpoints are cached.
+//
+// Requests are only identical if they are sent with the same credentials,
+// organization and project, and their bodies are identical. Chat and text
+// completion requests are only cached if they have a temperature of zero and
+// ask for a single choice, since the API would otherwise respond differently
+// to each. Streaming requests are never cached. The credentials of a
+// CredentialProvider are only known once a request is sent, so the requests
+// of a client using one are told apart by organization and project.
+func WithCache(cache Cache, endpoints ...string) ClientOption {
+	if len(endpoints) == 0 {
+		endpoints = DefaultCachedEndpoints
+	}
+	return func(c *Client) {
+		c.cache = cache
+		c.cachedEndpoints = map[string]bool{}
+		for _, endpoint := range endpoints {
+			c.cachedEndpoints[relativeEndpoint(endpoint)] = true
+		}
+	}
+}
+
+type bypassCacheKey struct{}
+
+// ContextWithoutCache returns a copy of ctx which bypasses the cache
+// of any client sending a request with it. The response is neither
+// read from nor stored in the cache.
+func ContextWithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+func relativeEndpoint(endpoint string) string {
+	endpoint = strings.TrimPrefix(endpoint, BaseURL)
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+	return strings.Trim(endpoint, "/")
+}
+
+// cacheKey returns the key of req in the client's cache,
+// or the empty string if the request should not be cached.
+func (c *Client) cacheKey(ctx context.Context, req *http.Request, endpoint string, body []byte) string {
+	if c.cache == nil || body == nil || !c.cachedEndpoints[relativeEndpoint(endpoint)] {
+		return ""
+	}
+	if bypass, _ := ctx.Value(bypassCacheKey{}).(bool); bypass {
+		return ""
+	}
+	if sampledEndpoints[relativeEndpoint(endpoint)] && !deterministic(body) {
+		return ""
+	}
+	return CacheKey(req.Method, req.URL.String(), req.Header, body)
+}
+
+// deterministic reports whether a request to a sampled endpoint asks for a
+// single choice with a temperature of zero, so that the API's response may
+// be reused.
+func deterministic(body []byte) bool {
+	var request struct {
+		Temperature *float64 `json:"temperature"`
+		N           *int64   `json:"n"`
+	}
+	if json.Unmarshal(body, &request) != nil {
+		return false
+	}
+	return request.Temperature != nil && *request.Temperature == 0 && (request.N == nil || *request.N <= 1)
+}
+
+// CacheKey returns the key of a request in a Cache. JSON bodies are
+// canonicalized, so that their formatting and key order do not matter.
+// The request's Authorization, OpenAI-Organization and OpenAI-Project
+// headers are part of the key, so that different credentials, organizations
+// and projects never share responses. Only a hash of them is kept.
+func CacheKey(method, endpoint string, header http.Header, body []byte) string {
+	if u, err := url.Parse(endpoint); err == nil {
+		u.RawQuery = u.Query().Encode()
+		endpoint = u.String()
+	}
+	var v any
+	if json.Unmarshal(body, &v) == nil {
+		if canonical, err := json.Marshal(v); err == nil {
+			body = canonical
+		}
+	}
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + endpoint + "\n"))
+	for _, key := range []string{auth.AuthHeaderKey, auth.OrgHeaderKey, auth.ProjectHeaderKey} {
+		hash.Write([]byte(key + ": " + header.Get(key) + "\n"))
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func expired(entry *CacheEntry, ttl time.Duration) bool {
+	return ttl > 0 && time.Since(entry.StoredAt) > ttl
+}
+
+// A MemoryCache is a Cache which holds entries in memory, evicting the least
+// recently used entry once full. It is safe for concurrent use.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache returns a MemoryCache holding at most capacity entries, each
+// of which expires after ttl. A capacity or ttl of zero is unlimited.
+func NewMemoryCache(capacity int, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  map[string]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	item := element.Value.(*memoryCacheItem)
+	if expired(item.entry, c.ttl) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return item.entry, true
+}
+
+func (c *MemoryCache) Set(key string, entry *CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*memoryCacheItem).entry = entry
+		c.order.MoveToFront(element)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(&memoryCacheItem{key: key, entry: entry})
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheItem).key)
+	}
+	return nil
+}
+
+// Len returns the number of entries in the cache, including expired
+// entries which have not yet been evicted.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// A DiskCache is a Cache which stores each entry as a file in a directory,
+// so that entries persist across processes. An entry's modification time
+// is the time it was stored.
+type DiskCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewDiskCache returns a DiskCache storing entries in dir, which is created
+// if needed, each of which expires after ttl. A ttl of zero is unlimited.
+func NewDiskCache(dir string, ttl time.Duration) *DiskCache {
+	return &DiskCache{dir: dir, ttl: ttl}
+}
+
+func (c *DiskCache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+func (c *DiskCache) Get(key string) (*CacheEntry, bool) {
+	info, err := os.Stat(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	entry := &CacheEntry{StoredAt: info.ModTime()}
+	if expired(entry, c.ttl) {
+		os.Remove(c.path(key))
+		return nil, false
+	}
+	if entry.Body, err = os.ReadFile(c.path(key)); err != nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (c *DiskCache) Set(key string, entry *CacheEntry) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	// Entries are written to a temporary file first,
+	// so that readers never see a partial entry.
+	file, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(entry.Body); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chtimes(file.Name(), entry.StoredAt, entry.StoredAt); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), c.path(key))
+}
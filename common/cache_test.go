@@ -0,0 +1,157 @@
+package common_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Kardbord/gopenai/common"
+)
+
+type cachedResponse struct {
+	Count int64 `json:"count"`
+	common.CacheMetadata
+}
+
+func TestClientCache(t *testing.T) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"count": ` + strconv.FormatInt(atomic.AddInt64(&requests, 1), 10) + `}`))
+	}))
+	defer server.Close()
+	client := common.NewClient(common.WithBaseURL(server.URL), common.WithCache(common.NewMemoryCache(10, 0)))
+
+	send := func(ctx context.Context, endpoint string, request map[string]any) *cachedResponse {
+		t.Helper()
+		resp, err := common.MakeRequestWithClientContext[map[string]any, cachedResponse](ctx, client, &request, common.BaseURL+endpoint, http.MethodPost, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	request := map[string]any{"model": "text-embedding-3-small", "input": []string{"a"}}
+
+	first := send(context.Background(), "embeddings", request)
+	if first.Cache == nil || first.Cache.Hit {
+		t.Fatalf("expected the first response to be stored, got %+v", first.Cache)
+	}
+	second := send(context.Background(), "embeddings", request)
+	if second.Cache == nil || !second.Cache.Hit || second.Count != first.Count || second.Cache.Key != first.Cache.Key {
+		t.Fatalf("expected the second response to be served from the cache, got %+v", second.Cache)
+	}
+
+	bypassed := send(common.ContextWithoutCache(context.Background()), "embeddings", request)
+	if bypassed.Cache != nil || bypassed.Count == first.Count {
+		t.Fatalf("expected the bypassed request to be sent, got %+v", bypassed)
+	}
+	if uncached := send(context.Background(), "files", request); uncached.Cache != nil {
+		t.Fatalf("expected requests to other endpoints not to be cached, got %+v", uncached.Cache)
+	}
+	if n := atomic.LoadInt64(&requests); n != 3 {
+		t.Fatalf("expected 3 requests to be sent, got %d", n)
+	}
+
+	// Chat requests are only cached when deterministic.
+	for _, request := range []map[string]any{
+		{"model": "gpt-4o", "messages": []string{}},
+		{"model": "gpt-4o", "messages": []string{}, "temperature": 1},
+		{"model": "gpt-4o", "messages": []string{}, "temperature": 0, "n": 2},
+	} {
+		if resp := send(context.Background(), "chat/completions", request); resp.Cache != nil {
+			t.Fatalf("expected the sampled request %v not to be cached, got %+v", request, resp.Cache)
+		}
+	}
+	request = map[string]any{"model": "gpt-4o", "messages": []string{}, "temperature": 0}
+	if first := send(context.Background(), "chat/completions", request); first.Cache == nil || first.Cache.Hit {
+		t.Fatalf("expected the deterministic request to be stored, got %+v", first.Cache)
+	}
+	if second := send(context.Background(), "chat/completions", request); second.Cache == nil || !second.Cache.Hit {
+		t.Fatalf("expected the deterministic request to be served from the cache, got %+v", second.Cache)
+	}
+}
+
+func TestClientCacheCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"count": 1}`))
+	}))
+	defer server.Close()
+	cache := common.NewMemoryCache(10, 0)
+	request := map[string]any{"model": "text-embedding-3-small", "input": []string{"a"}}
+
+	for _, opts := range [][]common.ClientOption{
+		{common.WithAPIKey("first")},
+		{common.WithAPIKey("second")},
+		{common.WithAPIKey("second"), common.WithOrganizationID("org")},
+		{common.WithAPIKey("second"), common.WithOrganizationID("org"), common.WithProjectID("project")},
+	} {
+		client := common.NewClient(append(opts, common.WithBaseURL(server.URL), common.WithCache(cache))...)
+		resp, err := common.MakeRequestWithClient[map[string]any, cachedResponse](client, &request, common.BaseURL+"embeddings", http.MethodPost, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Cache == nil || resp.Cache.Hit {
+			t.Fatalf("expected responses not to be shared between credentials, got %+v", resp.Cache)
+		}
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	header := http.Header{"Authorization": {"Bearer key"}}
+	key := common.CacheKey(http.MethodPost, common.BaseURL+"embeddings", header, []byte(`{"model": "m", "input": ["a"]}`))
+	if key != common.CacheKey(http.MethodPost, common.BaseURL+"embeddings", header, []byte(`{"input":["a"],"model":"m"}`)) {
+		t.Fatal("expected equivalent JSON bodies to have the same key")
+	}
+	if key == common.CacheKey(http.MethodPost, common.BaseURL+"moderations", header, []byte(`{"model": "m", "input": ["a"]}`)) {
+		t.Fatal("expected different endpoints to have different keys")
+	}
+	if key == common.CacheKey(http.MethodPost, common.BaseURL+"embeddings", http.Header{"Authorization": {"Bearer other"}}, []byte(`{"model": "m", "input": ["a"]}`)) {
+		t.Fatal("expected different credentials to have different keys")
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	cache := common.NewMemoryCache(2, time.Minute)
+	cache.Set("a", &common.CacheEntry{Body: []byte("a"), StoredAt: time.Now()})
+	cache.Set("b", &common.CacheEntry{Body: []byte("b"), StoredAt: time.Now()})
+	cache.Get("a")
+	cache.Set("c", &common.CacheEntry{Body: []byte("c"), StoredAt: time.Now()})
+	if _, ok := cache.Get("b"); ok {
+		t.Fatal("expected the least recently used entry to be evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("expected the recently used entry to be kept")
+	}
+
+	cache.Set("d", &common.CacheEntry{Body: []byte("d"), StoredAt: time.Now().Add(-time.Hour)})
+	if _, ok := cache.Get("d"); ok {
+		t.Fatal("expected the expired entry to be discarded")
+	}
+	if cache.Len() != 1 {
+		t.Fatalf("expected 1 entry, got %d", cache.Len())
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	if err := common.NewDiskCache(dir, time.Minute).Set("key", &common.CacheEntry{Body: []byte(`{}`), StoredAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := common.NewDiskCache(dir, time.Minute).Get("key")
+	if !ok || string(entry.Body) != `{}` {
+		t.Fatalf("expected the entry to persist, got %+v", entry)
+	}
+
+	cache := common.NewDiskCache(dir, time.Minute)
+	if err := cache.Set("old", &common.CacheEntry{Body: []byte(`{}`), StoredAt: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("old"); ok {
+		t.Fatal("expected the expired entry to be discarded")
+	}
+}
@@ -0,0 +1,262 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	auth "github.com/Kardbord/gopenai/authentication"
+)
+
+// A Client holds the configuration used to communicate with the OpenAI API,
+// such as the API key, organization, base URL and HTTP client. A Client is
+// safe for concurrent use by multiple goroutines, and should be reused
+// rather than created per request.
+//
+// Any value not configured on a Client falls back to the package-level
+// defaults in the authentication package.
+type Client struct {
+	apiKey         string
+	organizationID string
+	projectID      string
+	baseURL        string
+	httpClient     *http.Client
+	header         http.Header
+	retryPolicy    RetryPolicy
+	rateLimiter    RateLimiter
+	middlewares    []Middleware
+	credentials    auth.CredentialProvider
+
+	cache           Cache
+	cachedEndpoints map[string]bool
+}
+
+// A ClientOption configures a Client created with NewClient.
+type ClientOption func(*Client)
+
+// WithAPIKey sets the API key used to authenticate requests.
+func WithAPIKey(key string) ClientOption {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithCredentialProvider sets the CredentialProvider which provides the
+// API key, and optionally the organization and project IDs, of every
+// request. It takes precedence over the API key set with WithAPIKey.
+func WithCredentialProvider(provider auth.CredentialProvider) ClientOption {
+	return func(c *Client) {
+		c.credentials = provider
+	}
+}
+
+// WithOrganizationID sets the organization ID included in request headers.
+// An organization ID passed directly to a request function takes precedence.
+func WithOrganizationID(organizationID string) ClientOption {
+	return func(c *Client) {
+		c.organizationID = organizationID
+	}
+}
+
+// WithProjectID sets the project ID included in request headers.
+func WithProjectID(projectID string) ClientOption {
+	return func(c *Client) {
+		c.projectID = projectID
+	}
+}
+
+// WithBaseURL replaces BaseURL as the basis of all API endpoints, such as
+// to send requests to an OpenAI-compatible server like vLLM, Ollama or
+// LocalAI, or to a gateway in front of the API. The base URL should include
+// the API version, for example "http://localhost:8000/v1".
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.baseURL = normalizeBaseURL(baseURL)
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send requests.
+// If not provided, http.DefaultClient is used.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHeader adds a header which will be included in every request
+// sent by the client.
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// NewClient creates a Client configured with the given options.
+func NewClient(opts ...ClientOption) *Client {
+	c := &Client{
+		header: http.Header{},
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(c)
+		}
+	}
+	return c
+}
+
+var (
+	defaultClient      = NewClient()
+	defaultClientMutex = sync.RWMutex{}
+)
+
+// DefaultClient returns the Client used by the package-level request
+// functions, such as MakeRequest. Unless replaced with SetDefaultClient,
+// it is configured entirely by the authentication package.
+func DefaultClient() *Client {
+	defaultClientMutex.RLock()
+	defer defaultClientMutex.RUnlock()
+	return defaultClient
+}
+
+// SetDefaultClient replaces the Client returned by DefaultClient.
+// Passing nil restores the original default client.
+func SetDefaultClient(c *Client) {
+	if c == nil {
+		c = NewClient()
+	}
+	defaultClientMutex.Lock()
+	defer defaultClientMutex.Unlock()
+	defaultClient = c
+}
+
+// APIKey returns the API key used by the client.
+func (c *Client) APIKey() string {
+	if len(c.apiKey) != 0 {
+		return c.apiKey
+	}
+	return auth.APIKey()
+}
+
+// OrganizationID returns the default organization ID used by the client.
+func (c *Client) OrganizationID() string {
+	if len(c.organizationID) != 0 {
+		return c.organizationID
+	}
+	return auth.DefaultOrganizationID()
+}
+
+// ProjectID returns the default project ID used by the client.
+func (c *Client) ProjectID() string {
+	if len(c.projectID) != 0 {
+		return c.projectID
+	}
+	return auth.DefaultProjectID()
+}
+
+// CredentialProvider returns the CredentialProvider used by the client,
+// or nil if the client uses its API key.
+func (c *Client) CredentialProvider() auth.CredentialProvider {
+	if c.credentials != nil {
+		return c.credentials
+	}
+	if len(c.apiKey) != 0 {
+		return nil
+	}
+	return auth.DefaultCredentialProvider()
+}
+
+// BaseURL returns the basis of all API endpoints used by the client.
+func (c *Client) BaseURL() string {
+	if len(c.baseURL) != 0 {
+		return c.baseURL
+	}
+	return BaseURL
+}
+
+// HTTPClient returns the HTTP client used to send requests.
+func (c *Client) HTTPClient() *http.Client {
+	if c.httpClient != nil {
+		return c.httpClient
+	}
+	return http.DefaultClient
+}
+
+// Endpoint rewrites an endpoint built from BaseURL, such as chat.Endpoint,
+// to use the client's base URL instead.
+func (c *Client) Endpoint(endpoint string) string {
+	return c.EndpointContext(context.Background(), endpoint)
+}
+
+// Same as Endpoint, except a base URL set on ctx with ContextWithBaseURL
+// takes precedence over the client's base URL.
+func (c *Client) EndpointContext(ctx context.Context, endpoint string) string {
+	baseURL, ok := BaseURLFromContext(ctx)
+	if !ok {
+		baseURL = c.baseURL
+	}
+	if len(baseURL) == 0 || !strings.HasPrefix(endpoint, BaseURL) {
+		return endpoint
+	}
+	return baseURL + strings.TrimPrefix(endpoint, BaseURL)
+}
+
+type baseURLKey struct{}
+
+// ContextWithBaseURL returns a copy of ctx which overrides the base URL of
+// any client sending a request with it, such as to send a single request
+// to an OpenAI-compatible server.
+//
+//	ctx := common.ContextWithBaseURL(ctx, "http://localhost:11434/v1")
+//	resp, err := chat.MakeRequestContext(ctx, request, nil)
+func ContextWithBaseURL(ctx context.Context, baseURL string) context.Context {
+	return context.WithValue(ctx, baseURLKey{}, normalizeBaseURL(baseURL))
+}
+
+// BaseURLFromContext returns the base URL set on ctx with ContextWithBaseURL.
+func BaseURLFromContext(ctx context.Context) (string, bool) {
+	baseURL, ok := ctx.Value(baseURLKey{}).(string)
+	return baseURL, ok && len(baseURL) != 0
+}
+
+func normalizeBaseURL(baseURL string) string {
+	if len(baseURL) != 0 && !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	return baseURL
+}
+
+// SetRequestHeaders sets the content type, authentication, organization,
+// project and default headers of the client on req.
+func (c *Client) SetRequestHeaders(req *http.Request, contentType string, organizationID *string) {
+	if req == nil {
+		return
+	}
+	for key, values := range c.header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	req.Header.Set("Content-Type", contentType)
+	if apiKey := c.APIKey(); len(apiKey) != 0 {
+		// Servers which do not require authentication, such as a local
+		// OpenAI-compatible server, may reject an empty bearer token.
+		req.Header.Set(auth.AuthHeaderKey, auth.AuthHeaderPrefix+apiKey)
+	}
+
+	if organizationID != nil {
+		req.Header.Set(auth.OrgHeaderKey, *organizationID)
+	} else if orgID := c.OrganizationID(); len(orgID) != 0 {
+		req.Header.Set(auth.OrgHeaderKey, orgID)
+	}
+
+	if projectID := c.ProjectID(); len(projectID) != 0 {
+		req.Header.Set(auth.ProjectHeaderKey, projectID)
+	}
+}
+
+// Do sends req using the client's HTTP client, through its middlewares,
+// retrying as configured by the client's RetryPolicy.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.doWithRetries(req, c.roundTrip)
+}
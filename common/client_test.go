@@ -0,0 +1,79 @@
+package common_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Kardbord/gopenai/authentication"
+	"github.com/Kardbord/gopenai/common"
+)
+
+func TestClientHeaders(t *testing.T) {
+	client := common.NewClient(
+		common.WithAPIKey("key"),
+		common.WithOrganizationID("org"),
+		common.WithProjectID("project"),
+		common.WithHeader("X-Custom", "value"),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, common.BaseURL+"models", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	override := "other-org"
+	client.SetRequestHeaders(req, "application/json", &override)
+
+	expected := map[string]string{
+		authentication.AuthHeaderKey:    authentication.AuthHeaderPrefix + "key",
+		authentication.OrgHeaderKey:     override,
+		authentication.ProjectHeaderKey: "project",
+		"X-Custom":                      "value",
+		"Content-Type":                  "application/json",
+	}
+	for key, value := range expected {
+		if req.Header.Get(key) != value {
+			t.Errorf("expected header %s to be %q, got %q", key, value, req.Header.Get(key))
+		}
+	}
+}
+
+func TestClientEndpoint(t *testing.T) {
+	client := common.NewClient(common.WithBaseURL("http://localhost:8080/v1"))
+	endpoint := client.Endpoint(common.BaseURL + "chat/completions")
+	if endpoint != "http://localhost:8080/v1/chat/completions" {
+		t.Fatalf("unexpected endpoint: %s", endpoint)
+	}
+
+	if common.DefaultClient().Endpoint(common.BaseURL+"models") != common.BaseURL+"models" {
+		t.Fatal("default client should not rewrite endpoints")
+	}
+}
+
+func TestContextBaseURL(t *testing.T) {
+	client := common.NewClient(common.WithBaseURL("http://localhost:8080/v1"))
+	ctx := common.ContextWithBaseURL(context.Background(), "http://localhost:11434/v1/")
+	if endpoint := client.EndpointContext(ctx, common.BaseURL+"chat/completions"); endpoint != "http://localhost:11434/v1/chat/completions" {
+		t.Fatalf("unexpected endpoint: %s", endpoint)
+	}
+	if endpoint := common.DefaultClient().EndpointContext(ctx, common.BaseURL+"models"); endpoint != "http://localhost:11434/v1/models" {
+		t.Fatalf("unexpected endpoint: %s", endpoint)
+	}
+	if endpoint := client.EndpointContext(context.Background(), common.BaseURL+"models"); endpoint != "http://localhost:8080/v1/models" {
+		t.Fatalf("unexpected endpoint: %s", endpoint)
+	}
+}
+
+func TestClientWithoutAPIKey(t *testing.T) {
+	if len(authentication.APIKey()) != 0 {
+		t.Skip("an API key is set")
+	}
+	req, err := http.NewRequest(http.MethodGet, "http://localhost:11434/v1/models", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	common.NewClient().SetRequestHeaders(req, "application/json", nil)
+	if _, ok := req.Header[authentication.AuthHeaderKey]; ok {
+		t.Fatalf("unexpected %s header: %q", authentication.AuthHeaderKey, req.Header.Get(authentication.AuthHeaderKey))
+	}
+}
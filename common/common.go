@@ -3,6 +3,7 @@ package common
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,9 +13,9 @@ import (
 	"net/url"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
-
-	auth "github.com/Kardbord/gopenai/authentication"
+	"time"
 )
 
 const (
@@ -39,12 +40,69 @@ type ResponseError struct {
 
 	// The parameter that was invalid.
 	Param string `json:"param"`
+
+	// A machine-readable error code, such as "context_length_exceeded".
+	Code string `json:"code"`
 }
 
 func (e *ResponseError) Error() string {
 	return fmt.Sprintf("%s -> %s", e.Type, e.Message)
 }
 
+// UnmarshalJSON decodes an error object sent by the API. Errors sent by
+// OpenAI-compatible servers are also accepted, which may be a plain
+// message rather than an object, or have a numeric code.
+func (e *ResponseError) UnmarshalJSON(data []byte) error {
+	var message string
+	if json.Unmarshal(data, &message) == nil {
+		*e = ResponseError{Message: message}
+		return nil
+	}
+
+	var raw struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Param   string `json:"param"`
+		Code    any    `json:"code"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = ResponseError{
+		Message: raw.Message,
+		Type:    raw.Type,
+		Param:   raw.Param,
+	}
+	switch code := raw.Code.(type) {
+	case string:
+		e.Code = code
+	case float64:
+		e.Code = strconv.FormatFloat(code, 'f', -1, 64)
+	}
+	return nil
+}
+
+// parseResponseError returns the error contained in a response body, if
+// any. Besides the {"error": {...}} bodies sent by the API, this accepts
+// the {"object": "error", ...} bodies sent by some compatible servers.
+func parseResponseError(body []byte) *ResponseError {
+	respErr := responseErrorWrapper{}
+	if json.Unmarshal(body, &respErr) == nil && respErr.Error != nil {
+		return respErr.Error
+	}
+
+	var object struct {
+		Object string `json:"object"`
+	}
+	if json.Unmarshal(body, &object) == nil && object.Object == "error" {
+		e := &ResponseError{}
+		if json.Unmarshal(body, e) == nil {
+			return e
+		}
+	}
+	return nil
+}
+
 // A common usage information structure included in OpenAI API response bodies.
 type ResponseUsage struct {
 	PromptTokens     uint64 `json:"prompt_tokens"`
@@ -52,34 +110,90 @@ type ResponseUsage struct {
 	TotalTokens      uint64 `json:"total_tokens"`
 }
 
-// Send a request to the given OpenAI endpoint.
+// Send a request to the given OpenAI endpoint using the DefaultClient.
 // The method parameter should be an HTTP method, such as GET or POST.
 // The organizationID parameter is optional. If provided, it will be included in the request header.
 // If not provided, the authorization.DefaultOrganizationID will be used, if it is set.
 func MakeRequest[RequestT any, ResponseT any](request *RequestT, endpoint, method string, organizationID *string) (*ResponseT, error) {
-	var req *http.Request = nil
-	var err error = nil
-	if request != nil {
-		jsonData, err2 := json.Marshal(request)
-		if err2 != nil {
-			return nil, err2
+	return MakeRequestWithClientContext[RequestT, ResponseT](context.Background(), DefaultClient(), request, endpoint, method, organizationID)
+}
+
+// Same as MakeRequest, except the request is made with the given context.
+func MakeRequestContext[RequestT any, ResponseT any](ctx context.Context, request *RequestT, endpoint, method string, organizationID *string) (*ResponseT, error) {
+	return MakeRequestWithClientContext[RequestT, ResponseT](ctx, DefaultClient(), request, endpoint, method, organizationID)
+}
+
+// Same as MakeRequest, except the request is sent using the given client.
+// If client is nil, the DefaultClient is used.
+func MakeRequestWithClient[RequestT any, ResponseT any](client *Client, request *RequestT, endpoint, method string, organizationID *string) (*ResponseT, error) {
+	return MakeRequestWithClientContext[RequestT, ResponseT](context.Background(), client, request, endpoint, method, organizationID)
+}
+
+// Same as MakeRequestWithClient, except the request is made with the given context.
+func MakeRequestWithClientContext[RequestT any, ResponseT any](ctx context.Context, client *Client, request *RequestT, endpoint, method string, organizationID *string) (*ResponseT, error) {
+	if client == nil {
+		client = DefaultClient()
+	}
+	req, body, err := newJSONRequest(ctx, client, request, endpoint, method, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := client.cacheKey(ctx, req, endpoint, body)
+	if len(cacheKey) != 0 {
+		if entry, ok := client.cache.Get(cacheKey); ok {
+			response, err := decodeResponse[ResponseT](entry.Body)
+			if err == nil {
+				setCacheInfo(response, &CacheInfo{Key: cacheKey, Hit: true, StoredAt: entry.StoredAt})
+				return response, nil
+			}
+			// An entry which cannot be decoded is replaced.
 		}
-		req, err = http.NewRequest(method, endpoint, bytes.NewBuffer(jsonData))
-	} else {
-		req, err = http.NewRequest(method, endpoint, nil)
 	}
+	reservation, err := client.reserveRateLimit(ctx, requestOrNil(request), body)
 	if err != nil {
 		return nil, err
 	}
-	if req == nil {
-		return nil, errors.New("nil request created")
+	response, respBody, err := makeRequest[ResponseT](client, req, reservation)
+	if err != nil || len(cacheKey) == 0 {
+		return response, err
+	}
+	entry := &CacheEntry{Body: respBody, StoredAt: time.Now()}
+	// A response which cannot be stored is returned all the same.
+	if client.cache.Set(cacheKey, entry) == nil {
+		setCacheInfo(response, &CacheInfo{Key: cacheKey, StoredAt: entry.StoredAt})
 	}
-	SetRequestHeaders(req, "application/json", organizationID)
-	return makeRequest[ResponseT](req)
+	return response, nil
 }
 
+func setCacheInfo[ResponseT any](response *ResponseT, info *CacheInfo) {
+	if setter, ok := any(response).(cacheInfoSetter); ok {
+		setter.setCacheInfo(info)
+	}
+}
+
+// Send a multipart form to the given OpenAI endpoint using the DefaultClient.
 func MakeRequestWithForm[ResponseT any](form *bytes.Buffer, endpoint, method, contentType string, organizationID *string) (*ResponseT, error) {
-	req, err := http.NewRequest(method, endpoint, form)
+	return MakeRequestWithFormAndClientContext[ResponseT](context.Background(), DefaultClient(), form, endpoint, method, contentType, organizationID)
+}
+
+// Same as MakeRequestWithForm, except the request is made with the given context.
+func MakeRequestWithFormContext[ResponseT any](ctx context.Context, form *bytes.Buffer, endpoint, method, contentType string, organizationID *string) (*ResponseT, error) {
+	return MakeRequestWithFormAndClientContext[ResponseT](ctx, DefaultClient(), form, endpoint, method, contentType, organizationID)
+}
+
+// Same as MakeRequestWithForm, except the request is sent using the given client.
+// If client is nil, the DefaultClient is used.
+func MakeRequestWithFormAndClient[ResponseT any](client *Client, form *bytes.Buffer, endpoint, method, contentType string, organizationID *string) (*ResponseT, error) {
+	return MakeRequestWithFormAndClientContext[ResponseT](context.Background(), client, form, endpoint, method, contentType, organizationID)
+}
+
+// Same as MakeRequestWithFormAndClient, except the request is made with the given context.
+func MakeRequestWithFormAndClientContext[ResponseT any](ctx context.Context, client *Client, form *bytes.Buffer, endpoint, method, contentType string, organizationID *string) (*ResponseT, error) {
+	if client == nil {
+		client = DefaultClient()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, client.EndpointContext(ctx, endpoint), form)
 	if err != nil {
 		return nil, err
 	}
@@ -87,63 +201,104 @@ func MakeRequestWithForm[ResponseT any](form *bytes.Buffer, endpoint, method, co
 		return nil, errors.New("nil request created")
 	}
 
-	SetRequestHeaders(req, contentType, organizationID)
-	return makeRequest[ResponseT](req)
+	client.SetRequestHeaders(req, contentType, organizationID)
+	reservation, err := client.reserveRateLimit(ctx, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, _, err := makeRequest[ResponseT](client, req, reservation)
+	return response, err
 }
 
+// Sets the request headers of req as configured by the DefaultClient.
 func SetRequestHeaders(req *http.Request, contentType string, organizationID *string) {
+	DefaultClient().SetRequestHeaders(req, contentType, organizationID)
+}
+
+func newJSONRequest[RequestT any](ctx context.Context, client *Client, request *RequestT, endpoint, method string, organizationID *string) (*http.Request, []byte, error) {
+	endpoint = client.EndpointContext(ctx, endpoint)
+
+	var req *http.Request = nil
+	var jsonData []byte = nil
+	var err error = nil
+	if request != nil {
+		jsonData, err = json.Marshal(request)
+		if err != nil {
+			return nil, nil, err
+		}
+		req, err = http.NewRequestWithContext(ctx, method, endpoint, bytes.NewBuffer(jsonData))
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, endpoint, nil)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
 	if req == nil {
-		return
+		return nil, nil, errors.New("nil request created")
 	}
-	req.Header.Set("Content-Type", contentType)
-	req.Header.Set(auth.AuthHeaderKey, auth.AuthHeaderPrefix+auth.APIKey())
+	client.SetRequestHeaders(req, "application/json", organizationID)
+	return req, jsonData, nil
+}
 
-	if organizationID != nil {
-		req.Header.Set(auth.OrgHeaderKey, *organizationID)
-	} else if len(auth.DefaultOrganizationID()) != 0 {
-		req.Header.Set(auth.OrgHeaderKey, auth.DefaultOrganizationID())
+// requestOrNil avoids wrapping a nil request pointer in a non-nil interface.
+func requestOrNil[RequestT any](request *RequestT) any {
+	if request == nil {
+		return nil
 	}
+	return request
 }
 
-func makeRequest[ResponseT any](req *http.Request) (*ResponseT, error) {
+// makeRequest sends req, returning the decoded response along with its body.
+func makeRequest[ResponseT any](client *Client, req *http.Request, reservation *rateLimitReservation) (*ResponseT, []byte, error) {
 	if req == nil {
-		return nil, errors.New("nil request provided to makeRequest helper - this is a bug in the library")
+		return nil, nil, errors.New("nil request provided to makeRequest helper - this is a bug in the library")
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		client.updateRateLimit(reservation, nil, nil)
+		return nil, nil, err
 	}
 	if resp == nil {
-		return nil, errors.New("nil response received")
+		return nil, nil, errors.New("nil response received")
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if respBody == nil {
-		return nil, errors.New("unable to parse response body")
+		return nil, nil, errors.New("unable to parse response body")
+	}
+	client.updateRateLimit(reservation, resp, respBody)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil, newAPIError(resp, respBody)
+	}
+
+	var response ResponseT
+	if _, ok := any(response).([]byte); !ok && parseResponseError(respBody) != nil {
+		return nil, nil, newAPIError(resp, respBody)
 	}
+	decoded, err := decodeResponse[ResponseT](respBody)
+	if err != nil {
+		return nil, nil, err
+	}
+	return decoded, respBody, nil
+}
 
+func decodeResponse[ResponseT any](body []byte) (*ResponseT, error) {
 	var response ResponseT
 	if _, ok := any(response).([]byte); ok {
 		// Special case for handling binary return types.
 		// Defer to the caller to do what they will with
 		// the response.
 		v := reflect.ValueOf(&response).Elem()
-		v.Set(reflect.MakeSlice(v.Type(), len(respBody), cap(respBody)))
-		v.SetBytes(respBody)
-
-		respErr := responseErrorWrapper{}
-		json.Unmarshal(respBody, &respErr)
-		if respErr.Error != nil {
-			return &response, respErr.Error
-		}
+		v.Set(reflect.MakeSlice(v.Type(), len(body), cap(body)))
+		v.SetBytes(body)
 		return &response, nil
 	}
 
-	err = json.Unmarshal(respBody, &response)
+	err := json.Unmarshal(body, &response)
 	if err != nil {
 		return nil, err
 	}
@@ -157,6 +312,23 @@ func IsUrl(str string) bool {
 }
 
 func CreateFormFile(fieldname, filename, filepath string, writer *multipart.Writer) error {
+	return CreateFormFileContext(context.Background(), fieldname, filename, filepath, writer)
+}
+
+// Same as CreateFormFile, except a file retrieved from a URL is
+// downloaded with the given context.
+func CreateFormFileContext(ctx context.Context, fieldname, filename, filepath string, writer *multipart.Writer) error {
+	return CreateFormFileWithClientContext(ctx, nil, fieldname, filename, filepath, writer)
+}
+
+// Same as CreateFormFileContext, except a file retrieved from a URL is
+// downloaded with the given client's HTTP client, through its middlewares
+// and retries, but without its credentials. If client is nil, the
+// DefaultClient is used.
+func CreateFormFileWithClientContext(ctx context.Context, client *Client, fieldname, filename, filepath string, writer *multipart.Writer) error {
+	if client == nil {
+		client = DefaultClient()
+	}
 	file, err := writer.CreateFormFile(fieldname, filename)
 	if err != nil {
 		return err
@@ -164,7 +336,11 @@ func CreateFormFile(fieldname, filename, filepath string, writer *multipart.Writ
 
 	var fdata io.ReadCloser
 	if IsUrl(filepath) {
-		resp, err := http.Get(filepath)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, filepath, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.doWithRetries(req, client.unauthenticatedRoundTrip())
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,65 @@
+package common_test
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Kardbord/gopenai/authentication"
+	"github.com/Kardbord/gopenai/common"
+)
+
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestCreateFormFileWithClient(t *testing.T) {
+	attempt := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get(authentication.AuthHeaderKey); len(auth) != 0 {
+			t.Errorf("expected no credentials to be sent with the download, got %q", auth)
+		}
+		if attempt++; attempt == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("file contents"))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	middleware := 0
+	client := common.NewClient(
+		common.WithHTTPClient(&http.Client{Transport: transport}),
+		common.WithRetryPolicy(testRetryPolicy),
+		common.WithCredentialProvider(authentication.StaticKey("secret")),
+		common.WithMiddleware(func(next common.RoundTripFunc) common.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				middleware++
+				return next(req)
+			}
+		}),
+	)
+
+	buf := new(bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+	if err := common.CreateFormFileWithClientContext(context.Background(), client, "file", "file.txt", server.URL+"/file.txt", writer); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	if !strings.Contains(buf.String(), "file contents") {
+		t.Fatalf("expected the downloaded file in the form, got %q", buf)
+	}
+	if transport.requests != 2 || middleware != 2 {
+		t.Fatalf("expected the download to be retried through the client, got %d requests and %d middleware calls", transport.requests, middleware)
+	}
+}
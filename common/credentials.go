@@ -0,0 +1,58 @@
+package common
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	auth "github.com/Kardbord/gopenai/authentication"
+)
+
+// authenticate sets the authentication headers of req with credentials from
+// provider, then sends it with next. The credentials are released once the
+// request fails, or once its response body has been read to the end or closed.
+func authenticate(provider auth.CredentialProvider, next RoundTripFunc, req *http.Request) (*http.Response, error) {
+	creds, err := provider.Credentials(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set(auth.AuthHeaderKey, auth.AuthHeaderPrefix+creds.APIKey)
+	// The headers set from the client's defaults, or from an organization
+	// ID passed to a request function, are kept.
+	if len(creds.OrganizationID) != 0 && len(req.Header.Get(auth.OrgHeaderKey)) == 0 {
+		req.Header.Set(auth.OrgHeaderKey, creds.OrganizationID)
+	}
+	if len(creds.ProjectID) != 0 && len(req.Header.Get(auth.ProjectHeaderKey)) == 0 {
+		req.Header.Set(auth.ProjectHeaderKey, creds.ProjectID)
+	}
+
+	resp, err := next(req)
+	if err != nil || resp == nil {
+		creds.Release()
+		return resp, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: creds.Release}
+	return resp, nil
+}
+
+// A releaseBody calls release once it has been read to the end or closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *releaseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.once.Do(b.release)
+	}
+	return n, err
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
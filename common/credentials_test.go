@@ -0,0 +1,101 @@
+package common_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Kardbord/gopenai/authentication"
+	"github.com/Kardbord/gopenai/common"
+)
+
+func TestCredentialProvider(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, strings.TrimPrefix(r.Header.Get(authentication.AuthHeaderKey), authentication.AuthHeaderPrefix))
+		attempt := len(keys)
+		mu.Unlock()
+		if r.Header.Get(authentication.OrgHeaderKey) != "org-override" || r.Header.Get(authentication.ProjectHeaderKey) != "project" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		if attempt == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	attempts := 0
+	provider := authentication.RoundRobin(
+		authentication.Static(authentication.Credentials{APIKey: "a", OrganizationID: "org-a", ProjectID: "project"}),
+		authentication.StaticKey("b"),
+	)
+	client := common.NewClient(
+		common.WithAPIKey("ignored"),
+		common.WithBaseURL(server.URL),
+		common.WithRetryPolicy(testRetryPolicy),
+		common.WithCredentialProvider(provider),
+		common.WithMiddleware(func(next common.RoundTripFunc) common.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				attempts++
+				return next(req)
+			}
+		}),
+	)
+
+	org := "org-override"
+	if _, err := common.MakeRequestWithClient[any, map[string]any](client, nil, common.BaseURL+"models", http.MethodGet, &org); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(keys, ",") != "a,b" {
+		t.Fatalf("expected each attempt to use the next key, got %v", keys)
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestCredentialProviderRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.TrimPrefix(r.Header.Get(authentication.AuthHeaderKey), authentication.AuthHeaderPrefix)))
+	}))
+	defer server.Close()
+
+	client := common.NewClient(common.WithCredentialProvider(authentication.LeastUsed(
+		authentication.StaticKey("a"),
+		authentication.StaticKey("b"),
+	)))
+	send := func() *http.Response {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// The first response is left open, so its key remains in use.
+	open := send()
+	defer open.Body.Close()
+	keys := []string{}
+	for i := 0; i < 2; i++ {
+		resp := send()
+		key, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		keys = append(keys, string(key))
+	}
+	if strings.Join(keys, ",") != "b,b" {
+		t.Fatalf("expected the idle key to be reused once released, got %v", keys)
+	}
+}
@@ -0,0 +1,211 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// The response header containing the unique ID of a request.
+	RequestIDHeaderKey = "x-request-id"
+
+	maxErrorBodyLength = 256
+)
+
+// Error codes returned by the API in ResponseError.Code.
+const (
+	ErrorCodeContextLengthExceeded = "context_length_exceeded"
+	ErrorCodeRateLimitExceeded     = "rate_limit_exceeded"
+	ErrorCodeInsufficientQuota     = "insufficient_quota"
+	ErrorCodeInvalidAPIKey         = "invalid_api_key"
+)
+
+// Rate limit information sent by the API in [response headers].
+// Values not present in the response are left as zero.
+//
+// [response headers]: https://platform.openai.com/docs/guides/rate-limits/rate-limits-in-headers
+type RateLimit struct {
+	// The maximum number of requests permitted before exhausting the rate limit.
+	LimitRequests int64
+
+	// The maximum number of tokens permitted before exhausting the rate limit.
+	LimitTokens int64
+
+	// The remaining number of requests permitted before exhausting the rate limit.
+	RemainingRequests int64
+
+	// The remaining number of tokens permitted before exhausting the rate limit.
+	RemainingTokens int64
+
+	// The time until the request rate limit resets to its initial state.
+	ResetRequests time.Duration
+
+	// The time until the token rate limit resets to its initial state.
+	ResetTokens time.Duration
+
+	// How long the API asked the client to wait before retrying.
+	RetryAfter time.Duration
+}
+
+// ParseRateLimit extracts rate limit information from the headers of an API response.
+func ParseRateLimit(header http.Header) RateLimit {
+	parseInt := func(key string) int64 {
+		v, _ := strconv.ParseInt(header.Get(key), 10, 64)
+		return v
+	}
+	parseDuration := func(key string) time.Duration {
+		v, _ := time.ParseDuration(header.Get(key))
+		return v
+	}
+
+	return RateLimit{
+		LimitRequests:     parseInt("x-ratelimit-limit-requests"),
+		LimitTokens:       parseInt("x-ratelimit-limit-tokens"),
+		RemainingRequests: parseInt("x-ratelimit-remaining-requests"),
+		RemainingTokens:   parseInt("x-ratelimit-remaining-tokens"),
+		ResetRequests:     parseDuration("x-ratelimit-reset-requests"),
+		ResetTokens:       parseDuration("x-ratelimit-reset-tokens"),
+		RetryAfter:        parseRetryAfter(header),
+	}
+}
+
+func parseRetryAfter(header http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	retryAfter := header.Get("retry-after")
+	if len(retryAfter) == 0 {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(retryAfter, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if t, err := http.ParseTime(retryAfter); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// An APIError is returned by every request function when the API responds
+// with an unsuccessful status code, or with an error in the response body.
+// Use errors.As to retrieve it:
+//
+//	apiErr := new(common.APIError)
+//	if errors.As(err, &apiErr) && apiErr.IsRateLimited() {
+//		time.Sleep(apiErr.RateLimit.RetryAfter)
+//	}
+//
+// An APIError wraps its ResponseError, if the response contained one,
+// so errors.As may also be used to retrieve a *ResponseError directly.
+type APIError struct {
+	// The HTTP status code of the response.
+	StatusCode int
+
+	// The value of the x-request-id response header, which
+	// OpenAI support may ask for when troubleshooting.
+	RequestID string
+
+	// The headers of the response.
+	Header http.Header
+
+	// Rate limit information parsed from the response headers.
+	RateLimit RateLimit
+
+	// The error parsed from the response body. This is nil if
+	// the body did not contain an error object, for example when
+	// a proxy in front of the API responded with an HTML page.
+	Err *ResponseError
+
+	// The raw response body.
+	Body []byte
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get(RequestIDHeaderKey),
+		Header:     resp.Header,
+		RateLimit:  ParseRateLimit(resp.Header),
+		Body:       body,
+	}
+	e.Err = parseResponseError(body)
+	if e.Err == nil {
+		// FastAPI-based servers, such as some OpenAI-compatible
+		// servers, report errors as {"detail": "message"}.
+		var detail struct {
+			Detail string `json:"detail"`
+		}
+		if json.Unmarshal(body, &detail) == nil && len(detail.Detail) != 0 {
+			e.Err = &ResponseError{Message: detail.Detail}
+		}
+	}
+	return e
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	} else if len(e.Body) != 0 {
+		body := string(e.Body)
+		if len(body) > maxErrorBodyLength {
+			body = body[:maxErrorBodyLength] + "..."
+		}
+		msg += ": " + body
+	}
+	if len(e.RequestID) != 0 {
+		msg += fmt.Sprintf(" (request ID: %s)", e.RequestID)
+	}
+	return msg
+}
+
+// Unwrap returns the ResponseError parsed from the response body, if any.
+func (e *APIError) Unwrap() error {
+	if e.Err == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// The error code from the response body, if any.
+func (e *APIError) Code() string {
+	if e.Err == nil {
+		return ""
+	}
+	return e.Err.Code
+}
+
+// IsRateLimited reports whether the request was rejected for exceeding
+// a rate limit. Such requests may succeed if retried later.
+func (e *APIError) IsRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests && e.Code() != ErrorCodeInsufficientQuota
+}
+
+// IsQuotaExceeded reports whether the request was rejected because the
+// account has run out of credits or reached its maximum monthly spend.
+func (e *APIError) IsQuotaExceeded() bool {
+	return e.Code() == ErrorCodeInsufficientQuota
+}
+
+// IsAuthError reports whether the request was rejected due to an invalid
+// API key, or insufficient permissions for the requested resource.
+func (e *APIError) IsAuthError() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// IsContextLengthExceeded reports whether the request was rejected because
+// the input exceeded the model's maximum context length.
+func (e *APIError) IsContextLengthExceeded() bool {
+	return e.Code() == ErrorCodeContextLengthExceeded
+}
+
+// IsServerError reports whether the API failed to process the request
+// due to an error on its end.
+func (e *APIError) IsServerError() bool {
+	return e.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,91 @@
+package common_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Kardbord/gopenai/common"
+)
+
+func TestAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-request-id", "req_123")
+		switch r.URL.Path {
+		case "/v1/rate-limited":
+			w.Header().Set("retry-after", "2")
+			w.Header().Set("x-ratelimit-remaining-tokens", "0")
+			w.Header().Set("x-ratelimit-reset-tokens", "1m30s")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"message":"slow down","type":"requests","code":"rate_limit_exceeded"}}`))
+		case "/v1/context-length":
+			w.Write([]byte(`{"error":{"message":"too long","type":"invalid_request_error","param":"messages","code":"context_length_exceeded"}}`))
+		case "/v1/proxy":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("<html>Bad Gateway</html>"))
+		}
+	}))
+	defer server.Close()
+
+	client := common.NewClient(common.WithAPIKey("key"), common.WithBaseURL(server.URL+"/v1"))
+	makeRequest := func(path string) *common.APIError {
+		_, err := common.MakeRequestWithClient[any, map[string]any](client, nil, common.BaseURL+path, http.MethodGet, nil)
+		apiErr := new(common.APIError)
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("expected a common.APIError, got %v", err)
+		}
+		if apiErr.RequestID != "req_123" {
+			t.Fatalf("unexpected request ID: %q", apiErr.RequestID)
+		}
+		return apiErr
+	}
+
+	apiErr := makeRequest("rate-limited")
+	if !apiErr.IsRateLimited() || apiErr.IsAuthError() {
+		t.Fatalf("expected a rate limit error, got %v", apiErr)
+	}
+	if apiErr.RateLimit.RetryAfter != 2*time.Second || apiErr.RateLimit.ResetTokens != 90*time.Second {
+		t.Fatalf("unexpected rate limit: %+v", apiErr.RateLimit)
+	}
+
+	apiErr = makeRequest("context-length")
+	if !apiErr.IsContextLengthExceeded() {
+		t.Fatalf("expected a context length error, got %v", apiErr)
+	}
+	respErr := new(common.ResponseError)
+	if !errors.As(apiErr, &respErr) || respErr.Param != "messages" {
+		t.Fatalf("expected a wrapped common.ResponseError, got %v", respErr)
+	}
+
+	apiErr = makeRequest("proxy")
+	if !apiErr.IsServerError() || apiErr.Err != nil || string(apiErr.Body) != "<html>Bad Gateway</html>" {
+		t.Fatalf("unexpected error: %v", apiErr)
+	}
+}
+
+func TestCompatibleServerErrors(t *testing.T) {
+	bodies := map[string]string{
+		"/v1/ollama": `{"error":"model 'llama3' not found"}`,
+		"/v1/vllm":   `{"object":"error","message":"model 'llama3' not found","type":"NotFoundError","param":null,"code":404}`,
+		"/v1/detail": `{"detail":"model 'llama3' not found"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(bodies[r.URL.Path]))
+	}))
+	defer server.Close()
+
+	client := common.NewClient(common.WithBaseURL(server.URL + "/v1"))
+	for path := range bodies {
+		_, err := common.MakeRequestWithClient[any, map[string]any](client, nil, common.BaseURL+path[len("/v1/"):], http.MethodGet, nil)
+		apiErr := new(common.APIError)
+		if !errors.As(err, &apiErr) || apiErr.Err == nil || apiErr.Err.Message != "model 'llama3' not found" {
+			t.Fatalf("%s: unexpected error: %v", path, err)
+		}
+		if path == "/v1/vllm" && apiErr.Code() != "404" {
+			t.Fatalf("unexpected code: %q", apiErr.Code())
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package common
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A RoundTripFunc sends a single HTTP request and returns its response.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// A Middleware wraps the RoundTripFunc which sends each request, so that
+// it may inspect or modify requests and responses, or replace them entirely.
+//
+//	func Audit(next common.RoundTripFunc) common.RoundTripFunc {
+//		return func(req *http.Request) (*http.Response, error) {
+//			resp, err := next(req)
+//			audit(req, resp, err)
+//			return resp, err
+//		}
+//	}
+//
+// Middlewares are applied to every attempt of every request sent by a
+// Client, including JSON, multipart and streaming requests. Requests
+// retried by the client's RetryPolicy pass through the chain again.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddleware adds middlewares to the client. The first middleware
+// added is the outermost, seeing each request first and each response last.
+func WithMiddleware(middlewares ...Middleware) ClientOption {
+	return func(c *Client) {
+		for _, m := range middlewares {
+			if m != nil {
+				c.middlewares = append(c.middlewares, m)
+			}
+		}
+	}
+}
+
+// roundTrip authenticates a single attempt of req with the client's
+// CredentialProvider, if any, and sends it through the client's middlewares.
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	next := c.unauthenticatedRoundTrip()
+	if provider := c.CredentialProvider(); provider != nil {
+		return authenticate(provider, next, req)
+	}
+	return next(req)
+}
+
+// unauthenticatedRoundTrip returns a RoundTripFunc sending a single attempt
+// of a request through the client's middlewares, without its credentials.
+func (c *Client) unauthenticatedRoundTrip() RoundTripFunc {
+	next := RoundTripFunc(c.HTTPClient().Do)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		next = c.middlewares[i](next)
+	}
+	return next
+}
+
+// HeaderMiddleware sets the given headers on every request,
+// replacing any existing values.
+func HeaderMiddleware(header http.Header) Middleware {
+	header = header.Clone()
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			for key, values := range header {
+				req.Header.Del(key)
+				for _, v := range values {
+					req.Header.Add(key, v)
+				}
+			}
+			return next(req)
+		}
+	}
+}
+
+// LoggingMiddleware logs the method, URL, status and duration of every
+// request with logf, such as log.Printf. Headers and bodies, which may
+// contain credentials, are not logged.
+func LoggingMiddleware(logf func(format string, args ...any)) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			duration := time.Since(start)
+			if err != nil {
+				logf("%s %s: %v (%s)", req.Method, req.URL, err, duration)
+			} else {
+				logf("%s %s: %s (%s, request ID: %s)", req.Method, req.URL, resp.Status, duration, resp.Header.Get(RequestIDHeaderKey))
+			}
+			return resp, err
+		}
+	}
+}
+
+// An Exchange is a request and its response, as recorded by CaptureMiddleware.
+type Exchange struct {
+	Request     *http.Request
+	RequestBody []byte
+
+	// Nil if the request failed.
+	Response     *http.Response
+	ResponseBody []byte
+
+	// The error returned when sending the request, or when reading the
+	// response body.
+	Err error
+
+	// The time from sending the request to reading the whole response body.
+	Duration time.Duration
+}
+
+// CaptureMiddleware records the body of every request and response,
+// passing each Exchange to capture. Since a response body may be streamed,
+// capture is called once the body has been read to the end or closed,
+// rather than when the response is received. The bodies seen by the rest
+// of the client are unaffected.
+func CaptureMiddleware(capture func(*Exchange)) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			exchange := &Exchange{Request: req}
+			if req.Body != nil && req.Body != http.NoBody {
+				if req.GetBody != nil {
+					if body, err := req.GetBody(); err == nil {
+						exchange.RequestBody, _ = io.ReadAll(body)
+						body.Close()
+					}
+				} else {
+					exchange.RequestBody, _ = io.ReadAll(req.Body)
+					req.Body.Close()
+					req.Body = io.NopCloser(bytes.NewReader(exchange.RequestBody))
+				}
+			}
+
+			start := time.Now()
+			resp, err := next(req)
+			if err != nil || resp == nil {
+				exchange.Err = err
+				exchange.Duration = time.Since(start)
+				capture(exchange)
+				return resp, err
+			}
+
+			exchange.Response = resp
+			resp.Body = &captureBody{
+				body: resp.Body,
+				done: func(body []byte, err error) {
+					exchange.ResponseBody = body
+					exchange.Err = err
+					exchange.Duration = time.Since(start)
+					capture(exchange)
+				},
+			}
+			return resp, nil
+		}
+	}
+}
+
+// A captureBody copies a response body as it is read, calling done
+// once it has been read to the end or closed.
+type captureBody struct {
+	body io.ReadCloser
+	buf  bytes.Buffer
+	done func(body []byte, err error)
+	once sync.Once
+}
+
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.finish(nil)
+	} else if err != nil {
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *captureBody) Close() error {
+	err := b.body.Close()
+	b.finish(nil)
+	return err
+}
+
+func (b *captureBody) finish(err error) {
+	b.once.Do(func() {
+		b.done(b.buf.Bytes(), err)
+	})
+}
@@ -0,0 +1,97 @@
+package common_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Kardbord/gopenai/common"
+)
+
+func TestMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Custom") != "custom" || r.Header.Get("X-Signature") != "signed" {
+			t.Errorf("missing headers: %v", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if len(body) != 0 && string(body) != `{"model":"m"}` {
+			t.Errorf("request body was consumed: %q", body)
+		}
+		w.Header().Set(common.RequestIDHeaderKey, "req_123")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	order := []string{}
+	trace := func(name string) common.Middleware {
+		return func(next common.RoundTripFunc) common.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next(req)
+			}
+		}
+	}
+	sign := func(next common.RoundTripFunc) common.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("X-Signature", "signed")
+			return next(req)
+		}
+	}
+	logs := []string{}
+	logf := func(format string, args ...any) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}
+	exchanges := []*common.Exchange{}
+
+	client := common.NewClient(
+		common.WithAPIKey("secret"),
+		common.WithBaseURL(server.URL),
+		common.WithMiddleware(
+			trace("first"),
+			common.HeaderMiddleware(http.Header{"X-Custom": {"custom"}}),
+			common.LoggingMiddleware(logf),
+			common.CaptureMiddleware(func(e *common.Exchange) { exchanges = append(exchanges, e) }),
+			sign,
+			trace("last"),
+		),
+	)
+
+	request := map[string]string{"model": "m"}
+	resp, err := common.MakeRequestWithClient[map[string]string, map[string]bool](client, &request, common.BaseURL+"chat/completions", http.MethodPost, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(*resp)["ok"] {
+		t.Fatalf("unexpected response: %v", *resp)
+	}
+
+	raw, err := common.MakeRequestWithClient[any, []byte](client, nil, common.BaseURL+"files/file-123/content", http.MethodGet, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(*raw) != `{"ok":true}` {
+		t.Fatalf("unexpected response: %s", *raw)
+	}
+
+	if strings.Join(order, ",") != "first,last,first,last" {
+		t.Fatalf("unexpected middleware order: %v", order)
+	}
+	if len(logs) != 2 || !strings.Contains(logs[0], "POST "+server.URL+"/chat/completions: 200 OK") || !strings.Contains(logs[0], "req_123") {
+		t.Fatalf("unexpected logs: %v", logs)
+	}
+	for _, l := range logs {
+		if strings.Contains(l, "secret") {
+			t.Fatalf("credentials were logged: %s", l)
+		}
+	}
+	if len(exchanges) != 2 ||
+		string(exchanges[0].RequestBody) != `{"model":"m"}` ||
+		string(exchanges[0].ResponseBody) != `{"ok":true}` ||
+		string(exchanges[1].ResponseBody) != `{"ok":true}` ||
+		exchanges[1].Response.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected exchanges: %+v", exchanges)
+	}
+}
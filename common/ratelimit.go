@@ -0,0 +1,280 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// A RateLimiter throttles requests sent by a Client so that they stay
+// within the account's rate limits, rather than being rejected by the API.
+type RateLimiter interface {
+	// Wait blocks until a request for the given model, estimated to
+	// consume the given number of tokens, may be sent.
+	Wait(ctx context.Context, model string, tokens int64) error
+
+	// Update is called once the response to a request reserved with Wait
+	// has been received. The usage is nil if the response did not include
+	// any, and the header is nil if no response was received.
+	Update(model string, estimatedTokens int64, usage *ResponseUsage, header http.Header)
+}
+
+// Implemented by request types which can estimate how many tokens they
+// will consume, for the purpose of rate limiting. Requests which do not
+// implement TokenEstimator are estimated from the size of their body.
+type TokenEstimator interface {
+	EstimateTokens() int64
+}
+
+// EstimateTokens roughly estimates the number of tokens in text,
+// assuming the common rule of thumb of four characters per token.
+func EstimateTokens(text string) int64 {
+	return int64((utf8.RuneCountInString(text) + 3) / 4)
+}
+
+// WithRateLimiter sets the RateLimiter used to throttle requests.
+// By default, requests are not throttled.
+func WithRateLimiter(limiter RateLimiter) ClientOption {
+	return func(c *Client) {
+		c.rateLimiter = limiter
+	}
+}
+
+// A Budget is the maximum rate at which requests and tokens may be consumed.
+// A zero value for either field leaves that dimension unlimited.
+type Budget struct {
+	RequestsPerMinute int64
+	TokensPerMinute   int64
+}
+
+// A BudgetLimiter is a RateLimiter which enforces a requests-per-minute and
+// tokens-per-minute Budget for each model. Tokens are reserved based on the
+// estimated size of each request, then reconciled with the usage reported in
+// the response. The limiter also adapts to the x-ratelimit-remaining-* and
+// x-ratelimit-reset-* headers sent by the API, so that it stays accurate when
+// the same budget is shared with other processes.
+type BudgetLimiter struct {
+	defaultBudget Budget
+	budgets       map[string]Budget
+	limits        map[string]*modelLimit
+	mutex         sync.Mutex
+	now           func() time.Time
+}
+
+type modelLimit struct {
+	requests *bucket
+	tokens   *bucket
+}
+
+// NewBudgetLimiter creates a BudgetLimiter which applies the budget for each
+// model in budgets, and defaultBudget to any model not in budgets.
+func NewBudgetLimiter(defaultBudget Budget, budgets map[string]Budget) *BudgetLimiter {
+	l := &BudgetLimiter{
+		defaultBudget: defaultBudget,
+		budgets:       map[string]Budget{},
+		limits:        map[string]*modelLimit{},
+		now:           time.Now,
+	}
+	for model, budget := range budgets {
+		l.budgets[model] = budget
+	}
+	return l
+}
+
+func (l *BudgetLimiter) limit(model string) *modelLimit {
+	if ml, ok := l.limits[model]; ok {
+		return ml
+	}
+	budget, ok := l.budgets[model]
+	if !ok {
+		budget = l.defaultBudget
+	}
+	now := l.now()
+	ml := &modelLimit{
+		requests: newBucket(budget.RequestsPerMinute, now),
+		tokens:   newBucket(budget.TokensPerMinute, now),
+	}
+	l.limits[model] = ml
+	return ml
+}
+
+// Wait implements RateLimiter.
+func (l *BudgetLimiter) Wait(ctx context.Context, model string, tokens int64) error {
+	l.mutex.Lock()
+	ml := l.limit(model)
+	now := l.now()
+	delay := ml.requests.reserve(1, now)
+	if d := ml.tokens.reserve(float64(tokens), now); d > delay {
+		delay = d
+	}
+	l.mutex.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	if err := sleep(ctx, delay); err != nil {
+		l.mutex.Lock()
+		ml.requests.refund(ml.requests.reserved(1))
+		ml.tokens.refund(ml.tokens.reserved(float64(tokens)))
+		l.mutex.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Update implements RateLimiter.
+func (l *BudgetLimiter) Update(model string, estimatedTokens int64, usage *ResponseUsage, header http.Header) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	ml := l.limit(model)
+
+	if usage != nil {
+		// Only the tokens taken by Wait are refunded, which may
+		// be fewer than estimated.
+		ml.tokens.refund(ml.tokens.reserved(float64(estimatedTokens)) - float64(usage.TotalTokens))
+	}
+	if header != nil {
+		now := l.now()
+		if v := header.Get("x-ratelimit-remaining-requests"); len(v) != 0 {
+			rl := ParseRateLimit(header)
+			ml.requests.adapt(rl.RemainingRequests, rl.ResetRequests, now)
+		}
+		if v := header.Get("x-ratelimit-remaining-tokens"); len(v) != 0 {
+			rl := ParseRateLimit(header)
+			ml.tokens.adapt(rl.RemainingTokens, rl.ResetTokens, now)
+		}
+	}
+}
+
+// A token bucket which refills continuously over a minute.
+// A nil bucket is unlimited.
+type bucket struct {
+	capacity float64
+	rate     float64 // per nanosecond
+	level    float64
+	last     time.Time
+}
+
+func newBucket(perMinute int64, now time.Time) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &bucket{
+		capacity: float64(perMinute),
+		rate:     float64(perMinute) / float64(time.Minute),
+		level:    float64(perMinute),
+		last:     now,
+	}
+}
+
+func (b *bucket) advance(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.level += float64(elapsed) * b.rate
+		if b.level > b.capacity {
+			b.level = b.capacity
+		}
+		b.last = now
+	}
+}
+
+// reserve takes n from the bucket, returning how long to wait until
+// the bucket is no longer in debt.
+func (b *bucket) reserve(n float64, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.advance(now)
+	b.level -= b.reserved(n)
+	if b.level >= 0 {
+		return 0
+	}
+	return time.Duration(-b.level / b.rate)
+}
+
+// reserved returns how much reserve takes from the bucket for n, which
+// is at most its capacity, as a larger reservation could never be met.
+func (b *bucket) reserved(n float64) float64 {
+	if b != nil && n > b.capacity {
+		return b.capacity
+	}
+	return n
+}
+
+func (b *bucket) refund(n float64) {
+	if b == nil {
+		return
+	}
+	b.level += n
+	if b.level > b.capacity {
+		b.level = b.capacity
+	}
+}
+
+// adapt lowers the level of the bucket to the remaining budget reported
+// by the API, which may also be consumed by other clients.
+func (b *bucket) adapt(remaining int64, reset time.Duration, now time.Time) {
+	if b == nil {
+		return
+	}
+	b.advance(now)
+	if remaining <= 0 && reset > 0 {
+		// Exhausted until the reset.
+		debt := -float64(reset) * b.rate
+		if debt < b.level {
+			b.level = debt
+		}
+		return
+	}
+	if float64(remaining) < b.level {
+		b.level = float64(remaining)
+	}
+}
+
+type rateLimitReservation struct {
+	model  string
+	tokens int64
+}
+
+// reserveRateLimit waits for the client's RateLimiter, if any, to permit
+// a request with the given JSON body.
+func (c *Client) reserveRateLimit(ctx context.Context, request any, body []byte) (*rateLimitReservation, error) {
+	if c.rateLimiter == nil {
+		return nil, nil
+	}
+	r := &rateLimitReservation{}
+	if body != nil {
+		model := struct {
+			Model string `json:"model"`
+		}{}
+		json.Unmarshal(body, &model)
+		r.model = model.Model
+		r.tokens = int64(len(body)+3) / 4
+	}
+	if estimator, ok := request.(TokenEstimator); ok {
+		r.tokens = estimator.EstimateTokens()
+	}
+	if err := c.rateLimiter.Wait(ctx, r.model, r.tokens); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (c *Client) updateRateLimit(r *rateLimitReservation, resp *http.Response, body []byte) {
+	if c.rateLimiter == nil || r == nil {
+		return
+	}
+	var header http.Header
+	if resp != nil {
+		header = resp.Header
+	}
+	usage := struct {
+		Usage *ResponseUsage `json:"usage"`
+	}{}
+	if body != nil {
+		json.Unmarshal(body, &usage)
+	}
+	c.rateLimiter.Update(r.model, r.tokens, usage.Usage, header)
+}
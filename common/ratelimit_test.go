@@ -0,0 +1,154 @@
+package common_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Kardbord/gopenai/common"
+)
+
+func TestBudgetLimiterRequests(t *testing.T) {
+	// 600 requests per minute is one every 100ms, with a burst of 600.
+	limiter := common.NewBudgetLimiter(common.Budget{}, map[string]common.Budget{
+		"gpt-4o": {RequestsPerMinute: 600},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 600; i++ {
+		if err := limiter.Wait(ctx, "gpt-4o", 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := time.Now()
+	if err := limiter.Wait(ctx, "gpt-4o", 0); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected to wait for the budget to refill, waited %v", elapsed)
+	}
+
+	// Models without a budget are not limited.
+	for i := 0; i < 1000; i++ {
+		if err := limiter.Wait(ctx, "text-embedding-3-small", 1000); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBudgetLimiterTokens(t *testing.T) {
+	limiter := common.NewBudgetLimiter(common.Budget{TokensPerMinute: 6000}, nil)
+
+	// An over-estimate is refunded once the actual usage is known.
+	if err := limiter.Wait(context.Background(), "gpt-4o", 6000); err != nil {
+		t.Fatal(err)
+	}
+	limiter.Update("gpt-4o", 6000, &common.ResponseUsage{TotalTokens: 100}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, "gpt-4o", 5000); err != nil {
+		t.Fatalf("expected the over-estimate to be refunded: %v", err)
+	}
+
+	// The API reports that the budget is exhausted for the next minute.
+	header := http.Header{}
+	header.Set("x-ratelimit-remaining-tokens", "0")
+	header.Set("x-ratelimit-reset-tokens", "1m")
+	limiter.Update("gpt-4o", 5000, nil, header)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, "gpt-4o", 1); err == nil {
+		t.Fatal("expected to wait for the reported reset")
+	}
+}
+
+func TestBudgetLimiterEstimateAboveCapacity(t *testing.T) {
+	limiter := common.NewBudgetLimiter(common.Budget{TokensPerMinute: 6000}, nil)
+
+	// Only the 6000 tokens which could be reserved are reconciled,
+	// leaving 1000 once 5000 are used.
+	if err := limiter.Wait(context.Background(), "gpt-4o", 10000); err != nil {
+		t.Fatal(err)
+	}
+	limiter.Update("gpt-4o", 10000, &common.ResponseUsage{TotalTokens: 5000}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, "gpt-4o", 2000); err == nil {
+		t.Fatal("expected the refund not to exceed the reservation")
+	}
+}
+
+type recordingLimiter struct {
+	waited  []int64
+	updated []*common.ResponseUsage
+}
+
+func (l *recordingLimiter) Wait(ctx context.Context, model string, tokens int64) error {
+	l.waited = append(l.waited, tokens)
+	return nil
+}
+
+func (l *recordingLimiter) Update(model string, estimated int64, usage *common.ResponseUsage, header http.Header) {
+	l.updated = append(l.updated, usage)
+}
+
+type estimatedRequest struct {
+	Model string `json:"model"`
+}
+
+func (r *estimatedRequest) EstimateTokens() int64 { return 42 }
+
+func TestClientRateLimiter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"usage":{"prompt_tokens":40,"completion_tokens":0,"total_tokens":40}}`))
+	}))
+	defer server.Close()
+
+	limiter := &recordingLimiter{}
+	client := common.NewClient(common.WithBaseURL(server.URL), common.WithRateLimiter(limiter))
+	_, err := common.MakeRequestWithClient[estimatedRequest, map[string]any](client, &estimatedRequest{Model: "gpt-4o"}, common.BaseURL+"embeddings", http.MethodPost, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limiter.waited) != 1 || limiter.waited[0] != 42 {
+		t.Fatalf("unexpected estimates: %v", limiter.waited)
+	}
+	if len(limiter.updated) != 1 || limiter.updated[0] == nil || limiter.updated[0].TotalTokens != 40 {
+		t.Fatalf("unexpected usage: %v", limiter.updated)
+	}
+}
+
+func TestClientRateLimiterStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":30,\"completion_tokens\":2,\"total_tokens\":32}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	limiter := &recordingLimiter{}
+	client := common.NewClient(common.WithBaseURL(server.URL), common.WithRateLimiter(limiter))
+	stream, err := common.MakeStreamingRequestWithClientContext[estimatedRequest, map[string]any](context.Background(), client, &estimatedRequest{Model: "gpt-4o"}, common.BaseURL+"chat/completions", http.MethodPost, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	if len(limiter.updated) != 1 || limiter.updated[0] != nil {
+		t.Fatalf("expected only the headers to be reported before the stream is read, got %v", limiter.updated)
+	}
+	for stream.Next() {
+	}
+	stream.Close()
+	if len(limiter.updated) != 2 || limiter.updated[1] == nil || limiter.updated[1].TotalTokens != 32 {
+		t.Fatalf("expected the streamed usage to be reported once, got %v", limiter.updated)
+	}
+}
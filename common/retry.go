@@ -0,0 +1,219 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// A RetryPolicy controls how a Client retries requests which fail due to
+// rate limiting, server errors, or transient network errors. Requests are
+// retried when the response status code is 408, 409, 429 or 5xx, unless
+// the API indicates otherwise via the x-should-retry response header.
+//
+// When the API specifies how long to wait, through the Retry-After or
+// x-ratelimit-reset-* response headers, that delay is used. Otherwise,
+// the delay grows exponentially with each attempt.
+//
+// The zero value disables retries.
+type RetryPolicy struct {
+	// The maximum number of times a request is sent, including
+	// the first attempt. Values less than 2 disable retries.
+	MaxAttempts int
+
+	// The delay before the first retry. Defaults to 500ms.
+	InitialBackoff time.Duration
+
+	// The maximum delay between attempts, when not specified by the API.
+	// Defaults to 8s.
+	MaxBackoff time.Duration
+
+	// The factor by which the delay grows after each attempt. Defaults to 2.
+	Multiplier float64
+
+	// The fraction, between 0 and 1, of each delay which is randomized
+	// to avoid many clients retrying in lockstep. Defaults to 0.
+	Jitter float64
+}
+
+// A reasonable RetryPolicy for most applications.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     8 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.25,
+}
+
+// WithRetryPolicy sets the policy used to retry failed requests.
+// By default, requests are not retried.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// Backoff returns the delay before the given retry attempt, where
+// attempt 1 is the first retry, ignoring any delay requested by the API.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = 500 * time.Millisecond
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 8 * time.Second
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	backoff := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if backoff > float64(maxBackoff) {
+		backoff = float64(maxBackoff)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		backoff -= backoff * jitter * rand.Float64()
+	}
+	return time.Duration(backoff)
+}
+
+// delay returns the delay before the given retry attempt, preferring
+// any delay requested by the API in resp.
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		rl := ParseRateLimit(resp.Header)
+		if rl.RetryAfter > 0 {
+			return rl.RetryAfter
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			var reset time.Duration
+			if rl.RemainingRequests == 0 && rl.ResetRequests > reset {
+				reset = rl.ResetRequests
+			}
+			if rl.RemainingTokens == 0 && rl.ResetTokens > reset {
+				reset = rl.ResetTokens
+			}
+			if reset > 0 {
+				return reset
+			}
+		}
+	}
+	return p.Backoff(attempt)
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return isTransientError(err)
+	}
+	switch resp.Header.Get("x-should-retry") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusRequestTimeout,
+		resp.StatusCode == http.StatusConflict,
+		resp.StatusCode >= http.StatusInternalServerError:
+		return true
+	case resp.StatusCode == http.StatusTooManyRequests:
+		// Running out of quota is reported with the same status code as
+		// being rate limited, but will not resolve itself with time.
+		return !isQuotaExceeded(resp)
+	}
+	return false
+}
+
+func isQuotaExceeded(resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	respErr := responseErrorWrapper{}
+	return json.Unmarshal(body, &respErr) == nil && respErr.Error != nil && respErr.Error.Code == ErrorCodeInsufficientQuota
+}
+
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// rewind returns a copy of req with a fresh body, so that it may be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return r, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("unable to rewind request body")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = body
+	return r, nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// doWithRetries sends req with roundTrip, retrying as configured by the
+// client's RetryPolicy.
+func (c *Client) doWithRetries(req *http.Request, roundTrip RoundTripFunc) (*http.Response, error) {
+	policy := c.retryPolicy
+	attempt := req
+	for i := 1; ; i++ {
+		resp, err := roundTrip(attempt)
+		if i >= policy.MaxAttempts || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		next, rewindErr := rewind(req)
+		if rewindErr != nil {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err = sleep(req.Context(), policy.delay(i, resp)); err != nil {
+			return nil, err
+		}
+		attempt = next
+	}
+}
@@ -0,0 +1,90 @@
+package common_test
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Kardbord/gopenai/common"
+)
+
+var testRetryPolicy = common.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+}
+
+func TestRetryMultipartForm(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if err := r.ParseMultipartForm(1 << 20); err != nil || r.FormValue("model") != "whisper-1" {
+			t.Errorf("attempt %d: form was not rewound: %v", attempts, err)
+		}
+		if attempts < 3 {
+			w.Header().Set("retry-after-ms", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"text":"ok"}`))
+	}))
+	defer server.Close()
+
+	buf := new(bytes.Buffer)
+	writer := multipart.NewWriter(buf)
+	if err := common.CreateFormField("model", "whisper-1", writer); err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+
+	client := common.NewClient(common.WithBaseURL(server.URL), common.WithRetryPolicy(testRetryPolicy))
+	resp, err := common.MakeRequestWithFormAndClient[map[string]string](client, buf, common.BaseURL+"audio/transcriptions", http.MethodPost, writer.FormDataContentType(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if (*resp)["text"] != "ok" || attempts != 3 {
+		t.Fatalf("unexpected response after %d attempts: %v", attempts, *resp)
+	}
+}
+
+func TestRetryNotRetried(t *testing.T) {
+	for name, tc := range map[string]struct {
+		status int
+		body   string
+	}{
+		"bad request":        {http.StatusBadRequest, `{"error":{"message":"bad","type":"invalid_request_error"}}`},
+		"insufficient quota": {http.StatusTooManyRequests, `{"error":{"message":"quota","type":"insufficient_quota","code":"insufficient_quota"}}`},
+	} {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(tc.status)
+			w.Write([]byte(tc.body))
+		}))
+
+		client := common.NewClient(common.WithBaseURL(server.URL), common.WithRetryPolicy(testRetryPolicy))
+		_, err := common.MakeRequestWithClient[any, map[string]any](client, nil, common.BaseURL+"models", http.MethodGet, nil)
+		server.Close()
+
+		apiErr := new(common.APIError)
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != tc.status {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if attempts != 1 {
+			t.Fatalf("%s: expected 1 attempt, got %d", name, attempts)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := common.RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	for attempt, expected := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second} {
+		if backoff := policy.Backoff(attempt); backoff != expected {
+			t.Errorf("attempt %d: expected %v, got %v", attempt, expected, backoff)
+		}
+	}
+}
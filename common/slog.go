@@ -0,0 +1,339 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	auth "github.com/Kardbord/gopenai/authentication"
+)
+
+// The number of bytes of each body logged when LogBodies is given a limit
+// which is not positive.
+const DefaultLogBodyLimit = 1024
+
+// The value logged in place of a redacted header.
+const RedactedValue = "[REDACTED]"
+
+// The headers which are always redacted when headers are logged.
+var redactedHeaders = []string{
+	auth.AuthHeaderKey,
+	"Api-Key",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// A LogOption configures the logging of SlogMiddleware and WithLogger.
+type LogOption func(*logConfig)
+
+type logConfig struct {
+	level      slog.Level
+	errorLevel slog.Level
+	bodyLimit  int
+	headers    bool
+	redact     map[string]bool
+}
+
+// LogLevel sets the level at which successful requests are logged.
+// The default is slog.LevelInfo.
+func LogLevel(level slog.Level) LogOption {
+	return func(c *logConfig) {
+		c.level = level
+	}
+}
+
+// LogErrorLevel sets the level at which failed requests, and requests
+// which received an error response, are logged. The default is slog.LevelError.
+func LogErrorLevel(level slog.Level) LogOption {
+	return func(c *logConfig) {
+		c.errorLevel = level
+	}
+}
+
+// LogBodies logs the JSON body of each request and the body of each JSON
+// or streamed response, truncated to limit bytes. Bodies may contain
+// prompts, completions and other sensitive data, and are not logged by
+// default. Multipart and binary bodies, such as file uploads and
+// generated speech, are never logged.
+func LogBodies(limit int) LogOption {
+	return func(c *logConfig) {
+		if limit <= 0 {
+			limit = DefaultLogBodyLimit
+		}
+		c.bodyLimit = limit
+	}
+}
+
+// LogHeaders logs the headers of each request and response. The values of
+// the Authorization, Api-Key, Proxy-Authorization, Cookie and Set-Cookie
+// headers, along with any given keys, are replaced with RedactedValue.
+func LogHeaders(redact ...string) LogOption {
+	return func(c *logConfig) {
+		c.headers = true
+		for _, key := range redact {
+			c.redact[http.CanonicalHeaderKey(key)] = true
+		}
+	}
+}
+
+// WithLogger logs every request sent by the client with logger, as
+// described by SlogMiddleware.
+func WithLogger(logger *slog.Logger, opts ...LogOption) ClientOption {
+	return WithMiddleware(SlogMiddleware(logger, opts...))
+}
+
+// SlogMiddleware logs every request with logger, or with slog.Default if
+// logger is nil. Each request is logged once its response body has been
+// read to the end or closed, with its method, URL, status, duration,
+// request ID and model, and the token usage reported by the API.
+// Credentials are never logged. Unless LogBodies is given, response bodies
+// are not buffered, and only their end is kept to find the token usage.
+func SlogMiddleware(logger *slog.Logger, opts ...LogOption) Middleware {
+	config := &logConfig{
+		level:      slog.LevelInfo,
+		errorLevel: slog.LevelError,
+		redact:     map[string]bool{},
+	}
+	for _, key := range redactedHeaders {
+		config.redact[http.CanonicalHeaderKey(key)] = true
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			l := logger
+			if l == nil {
+				l = slog.Default()
+			}
+			var requestBody []byte
+			if isJSON(req.Header.Get("Content-Type")) && req.GetBody != nil {
+				if body, err := req.GetBody(); err == nil {
+					requestBody, _ = io.ReadAll(body)
+					body.Close()
+				}
+			}
+
+			start := time.Now()
+			resp, err := next(req)
+			if err != nil || resp == nil {
+				config.log(l, req, requestBody, nil, nil, nil, err, time.Since(start))
+				return resp, err
+			}
+
+			contentType := resp.Header.Get("Content-Type")
+			if !isJSON(contentType) && !isEventStream(contentType) {
+				config.log(l, req, requestBody, resp, nil, nil, nil, time.Since(start))
+				return resp, nil
+			}
+			if config.bodyLimit > 0 {
+				resp.Body = &captureBody{
+					body: resp.Body,
+					done: func(body []byte, err error) {
+						config.log(l, req, requestBody, resp, body, parseUsage(resp, body), err, time.Since(start))
+					},
+				}
+				return resp, nil
+			}
+			resp.Body = &usageBody{
+				body: resp.Body,
+				done: func(usage *ResponseUsage, err error) {
+					config.log(l, req, requestBody, resp, nil, usage, err, time.Since(start))
+				},
+			}
+			return resp, nil
+		}
+	}
+}
+
+func (c *logConfig) log(logger *slog.Logger, req *http.Request, requestBody []byte, resp *http.Response, responseBody []byte, usage *ResponseUsage, err error, duration time.Duration) {
+	level := c.level
+	if err != nil || resp == nil || resp.StatusCode >= http.StatusBadRequest {
+		level = c.errorLevel
+	}
+	ctx := req.Context()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+	}
+	var request struct {
+		Model string `json:"model"`
+	}
+	if json.Unmarshal(requestBody, &request) == nil && len(request.Model) > 0 {
+		attrs = append(attrs, slog.String("model", request.Model))
+	}
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		if id := resp.Header.Get(RequestIDHeaderKey); len(id) > 0 {
+			attrs = append(attrs, slog.String("request_id", id))
+		}
+	}
+	attrs = append(attrs, slog.Duration("duration", duration))
+	if usage != nil {
+		attrs = append(attrs, slog.Group("usage",
+			slog.Uint64("prompt_tokens", usage.PromptTokens),
+			slog.Uint64("completion_tokens", usage.CompletionTokens),
+			slog.Uint64("total_tokens", usage.TotalTokens),
+		))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	if c.headers {
+		attrs = append(attrs, slog.Any("request_headers", c.redactHeaders(req.Header)))
+		if resp != nil {
+			attrs = append(attrs, slog.Any("response_headers", c.redactHeaders(resp.Header)))
+		}
+	}
+	if c.bodyLimit > 0 {
+		if len(requestBody) > 0 {
+			attrs = append(attrs, slog.String("request_body", truncate(requestBody, c.bodyLimit)))
+		}
+		if len(responseBody) > 0 {
+			attrs = append(attrs, slog.String("response_body", truncate(responseBody, c.bodyLimit)))
+		}
+	}
+
+	logger.LogAttrs(ctx, level, "openai request", attrs...)
+}
+
+func (c *logConfig) redactHeaders(header http.Header) http.Header {
+	redacted := header.Clone()
+	for key := range redacted {
+		if c.redact[http.CanonicalHeaderKey(key)] {
+			redacted[key] = []string{RedactedValue}
+		}
+	}
+	return redacted
+}
+
+// parseUsage returns the token usage reported in a JSON response body,
+// or in the last chunk of a streamed response which reports it.
+func parseUsage(resp *http.Response, body []byte) *ResponseUsage {
+	if resp == nil || len(body) == 0 {
+		return nil
+	}
+	var response struct {
+		Usage *ResponseUsage `json:"usage"`
+	}
+	if !isEventStream(resp.Header.Get("Content-Type")) {
+		if json.Unmarshal(body, &response) != nil {
+			return nil
+		}
+		return response.Usage
+	}
+
+	var usage *ResponseUsage
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(nil, len(body)+1)
+	for scanner.Scan() {
+		data, ok := bytes.CutPrefix(scanner.Bytes(), []byte("data:"))
+		if !ok || !bytes.Contains(data, []byte(`"usage"`)) {
+			continue
+		}
+		response.Usage = nil
+		if json.Unmarshal(bytes.TrimSpace(data), &response) == nil && response.Usage != nil {
+			usage = response.Usage
+		}
+	}
+	return usage
+}
+
+// The number of bytes kept at the end of a response body to find its token
+// usage when bodies are not logged. The usage is reported at the end of JSON
+// responses, and in the last chunk of streamed responses.
+const usageTailSize = 4096
+
+// A usageBody keeps the end of a response body as it is read, calling done
+// with the token usage reported there once it has been read to the end or
+// closed. Unlike a captureBody, it never holds the whole body.
+type usageBody struct {
+	body io.ReadCloser
+	tail []byte
+	done func(usage *ResponseUsage, err error)
+	once sync.Once
+}
+
+func (b *usageBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n >= usageTailSize {
+		b.tail = append(b.tail[:0], p[n-usageTailSize:n]...)
+	} else {
+		b.tail = append(b.tail, p[:n]...)
+		if len(b.tail) > 2*usageTailSize {
+			b.tail = append(b.tail[:0], b.tail[len(b.tail)-usageTailSize:]...)
+		}
+	}
+	if err == io.EOF {
+		b.finish(nil)
+	} else if err != nil {
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *usageBody) Close() error {
+	err := b.body.Close()
+	b.finish(nil)
+	return err
+}
+
+func (b *usageBody) finish(err error) {
+	b.once.Do(func() {
+		b.done(lastUsage(b.tail), err)
+	})
+}
+
+// lastUsage returns the last token usage reported in the end of a JSON
+// response body or of a streamed response.
+func lastUsage(tail []byte) *ResponseUsage {
+	key := []byte(`"usage"`)
+	for end := len(tail); ; {
+		i := bytes.LastIndex(tail[:end], key)
+		if i < 0 {
+			return nil
+		}
+		end = i
+		if i > 0 && tail[i-1] == '\\' {
+			continue
+		}
+		value, ok := bytes.CutPrefix(bytes.TrimSpace(tail[i+len(key):]), []byte(":"))
+		if !ok {
+			continue
+		}
+		var usage *ResponseUsage
+		if json.NewDecoder(bytes.NewReader(value)).Decode(&usage) == nil {
+			return usage
+		}
+	}
+}
+
+func truncate(body []byte, limit int) string {
+	if len(body) <= limit {
+		return string(body)
+	}
+	return string(body[:limit]) + "...[truncated]"
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func isEventStream(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "text/event-stream"
+}
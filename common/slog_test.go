@@ -0,0 +1,170 @@
+package common_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Kardbord/gopenai/common"
+)
+
+func TestSlogMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(common.RequestIDHeaderKey, "req_123")
+		switch r.URL.Path {
+		case "/chat/completions":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"chatcmpl-1","usage":{"prompt_tokens":9,"completion_tokens":2,"total_tokens":11}}`))
+		case "/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: {\"choices\":[]}\n\ndata: {\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":1,\"total_tokens\":6}}\n\ndata: [DONE]\n\n"))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"not found"}}`))
+		}
+	}))
+	defer server.Close()
+
+	logs := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := common.NewClient(
+		common.WithAPIKey("secret"),
+		common.WithBaseURL(server.URL),
+		common.WithLogger(logger,
+			common.LogLevel(slog.LevelDebug),
+			common.LogErrorLevel(slog.LevelWarn),
+			common.LogBodies(16),
+			common.LogHeaders("X-Custom-Secret"),
+		),
+		common.WithHeader("X-Custom-Secret", "hidden"),
+	)
+
+	request := map[string]string{"model": "gpt-4o", "prompt": "a long prompt which is truncated"}
+	if _, err := common.MakeRequestWithClient[map[string]string, map[string]any](client, &request, common.BaseURL+"chat/completions", http.MethodPost, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := common.MakeRequestWithClient[map[string]string, map[string]any](client, &request, common.BaseURL+"missing", http.MethodPost, nil); err == nil {
+		t.Fatal("expected an error")
+	}
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if strings.Contains(logs.String(), "secret") || strings.Contains(logs.String(), "hidden") {
+		t.Fatalf("credentials were logged: %s", logs)
+	}
+
+	type record struct {
+		Level        string
+		Msg          string
+		Method       string
+		URL          string
+		Model        string
+		Status       int
+		RequestID    string `json:"request_id"`
+		Usage        *common.ResponseUsage
+		RequestBody  string              `json:"request_body"`
+		ResponseBody string              `json:"response_body"`
+		Headers      map[string][]string `json:"request_headers"`
+	}
+	records := []record{}
+	decoder := json.NewDecoder(logs)
+	for decoder.More() {
+		r := record{}
+		if err := decoder.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+
+	chat := records[0]
+	if chat.Level != "DEBUG" || chat.Msg != "openai request" || chat.Method != http.MethodPost ||
+		chat.URL != server.URL+"/chat/completions" || chat.Model != "gpt-4o" || chat.Status != http.StatusOK ||
+		chat.RequestID != "req_123" || chat.Usage == nil || chat.Usage.TotalTokens != 11 {
+		t.Fatalf("unexpected record: %+v", chat)
+	}
+	if chat.RequestBody != `{"model":"gpt-4o...[truncated]` || chat.ResponseBody != `{"id":"chatcmpl-...[truncated]` {
+		t.Fatalf("unexpected bodies: %q, %q", chat.RequestBody, chat.ResponseBody)
+	}
+	if chat.Headers["Authorization"][0] != common.RedactedValue || chat.Headers["X-Custom-Secret"][0] != common.RedactedValue {
+		t.Fatalf("headers were not redacted: %v", chat.Headers)
+	}
+
+	if missing := records[1]; missing.Level != "WARN" || missing.Status != http.StatusNotFound {
+		t.Fatalf("unexpected record: %+v", missing)
+	}
+	if stream := records[2]; stream.Level != "DEBUG" || stream.Usage == nil || stream.Usage.CompletionTokens != 1 {
+		t.Fatalf("unexpected record: %+v", stream)
+	}
+}
+
+func TestSlogMiddlewareWithoutBodies(t *testing.T) {
+	content := strings.Repeat("a \\\"usage\\\": ", 1000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/chat/completions":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"choices":[{"message":{"content":"` + content + `"}}],` + "\n" + `"usage": {"prompt_tokens":9,"completion_tokens":2,"total_tokens":11}}`))
+		case "/stream":
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: {\"usage\":null,\"choices\":[{\"delta\":{\"content\":\"" + content + "\"}}]}\n\n"))
+			w.Write([]byte("data: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":1,\"total_tokens\":6}}\n\ndata: [DONE]\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	logs := &bytes.Buffer{}
+	client := common.NewClient(
+		common.WithBaseURL(server.URL),
+		common.WithLogger(slog.New(slog.NewJSONHandler(logs, nil))),
+	)
+	request := map[string]string{"model": "gpt-4o"}
+	if _, err := common.MakeRequestWithClient[map[string]string, map[string]any](client, &request, common.BaseURL+"chat/completions", http.MethodPost, nil); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	expected := []uint64{11, 6}
+	decoder := json.NewDecoder(logs)
+	i := 0
+	for ; decoder.More(); i++ {
+		var r struct {
+			Usage        *common.ResponseUsage
+			ResponseBody *string `json:"response_body"`
+		}
+		if err := decoder.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		if i >= len(expected) || r.Usage == nil || r.Usage.TotalTokens != expected[i] || r.ResponseBody != nil {
+			t.Fatalf("unexpected record %d: %+v", i, r)
+		}
+	}
+	if i != len(expected) {
+		t.Fatalf("expected %d records, got %d", len(expected), i)
+	}
+}
@@ -0,0 +1,250 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// The data sent by the API to signal the end of a stream.
+const StreamDoneData = "[DONE]"
+
+// Options for streaming responses.
+type StreamOptions struct {
+	// If set, an additional chunk will be streamed before the data: [DONE]
+	// message. The usage field on this chunk shows the token usage statistics
+	// for the entire request, and the choices field will always be an empty
+	// array. All other chunks will also include a usage field, but with a
+	// null value.
+	IncludeUsage bool `json:"include_usage,omitempty"`
+}
+
+// A single [server-sent event].
+//
+// [server-sent event]: https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+type Event struct {
+	ID    string
+	Event string
+	Data  []byte
+}
+
+// An EventDecoder reads server-sent events from a stream.
+type EventDecoder struct {
+	reader *bufio.Reader
+}
+
+// NewEventDecoder creates an EventDecoder reading from r.
+func NewEventDecoder(r io.Reader) *EventDecoder {
+	return &EventDecoder{reader: bufio.NewReader(r)}
+}
+
+// Next returns the next event in the stream.
+// It returns io.EOF once the stream has been exhausted.
+func (d *EventDecoder) Next() (*Event, error) {
+	var event *Event
+	for {
+		line, err := d.reader.ReadBytes('\n')
+		if len(line) == 0 && err != nil {
+			if errors.Is(err, io.EOF) && event != nil {
+				// The stream ended without a trailing blank line.
+				return event, nil
+			}
+			return nil, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		if len(line) == 0 {
+			if event != nil {
+				return event, nil
+			}
+			continue
+		}
+		if line[0] == ':' {
+			// Comment line, used by some servers as a keep-alive.
+			continue
+		}
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		if event == nil {
+			event = &Event{}
+		}
+		switch string(field) {
+		case "data":
+			if event.Data != nil {
+				event.Data = append(event.Data, '\n')
+			}
+			event.Data = append(event.Data, value...)
+		case "event":
+			event.Event = string(value)
+		case "id":
+			event.ID = string(value)
+		}
+	}
+}
+
+// A Stream decodes the data of each server-sent event in a
+// streaming API response into a ChunkT.
+//
+//	for stream.Next() {
+//		chunk := stream.Current()
+//		...
+//	}
+//	if err := stream.Err(); err != nil {
+//		...
+//	}
+type Stream[ChunkT any] struct {
+	body     io.ReadCloser
+	response *http.Response
+	decoder  *EventDecoder
+	current  *ChunkT
+	err      error
+	done     bool
+
+	// Called once with the usage reported by the stream, if any,
+	// when the stream ends or is closed.
+	onUsage func(usage *ResponseUsage)
+	usage   *ResponseUsage
+}
+
+// NewStream creates a Stream which reads events from body.
+// The stream takes ownership of body, which is closed by Close.
+func NewStream[ChunkT any](body io.ReadCloser) *Stream[ChunkT] {
+	return &Stream[ChunkT]{
+		body:    body,
+		decoder: NewEventDecoder(body),
+	}
+}
+
+// Next advances the stream to the next chunk, which is then available
+// through Current. It returns false when the stream ends, either because
+// the API sent the [DONE] message or because an error occurred.
+func (s *Stream[ChunkT]) Next() bool {
+	if s.done || s.err != nil {
+		return false
+	}
+	for {
+		event, err := s.decoder.Next()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.err = err
+			}
+			s.done = true
+			s.reportUsage()
+			return false
+		}
+		if len(event.Data) == 0 {
+			continue
+		}
+		if string(bytes.TrimSpace(event.Data)) == StreamDoneData {
+			s.done = true
+			s.reportUsage()
+			return false
+		}
+
+		if respErr := parseResponseError(event.Data); respErr != nil {
+			if s.response != nil {
+				s.err = newAPIError(s.response, event.Data)
+			} else {
+				s.err = respErr
+			}
+			s.reportUsage()
+			return false
+		}
+
+		var chunk ChunkT
+		if err = json.Unmarshal(event.Data, &chunk); err != nil {
+			s.err = err
+			s.reportUsage()
+			return false
+		}
+		if s.onUsage != nil {
+			usage := struct {
+				Usage *ResponseUsage `json:"usage"`
+			}{}
+			if json.Unmarshal(event.Data, &usage) == nil && usage.Usage != nil {
+				s.usage = usage.Usage
+			}
+		}
+		s.current = &chunk
+		return true
+	}
+}
+
+// Current returns the chunk read by the most recent call to Next.
+func (s *Stream[ChunkT]) Current() *ChunkT {
+	return s.current
+}
+
+// Err returns the first error encountered while reading the stream, if any.
+func (s *Stream[ChunkT]) Err() error {
+	return s.err
+}
+
+// Close closes the underlying response body. It is safe to call
+// Close before the stream has been exhausted.
+func (s *Stream[ChunkT]) Close() error {
+	s.done = true
+	s.reportUsage()
+	return s.body.Close()
+}
+
+func (s *Stream[ChunkT]) reportUsage() {
+	if s.onUsage != nil {
+		s.onUsage(s.usage)
+		s.onUsage = nil
+	}
+}
+
+// Send a streaming request to the given OpenAI endpoint using the given client,
+// returning a Stream of the server-sent events in the response.
+// If client is nil, the DefaultClient is used. The caller must close the stream.
+func MakeStreamingRequestWithClientContext[RequestT any, ChunkT any](ctx context.Context, client *Client, request *RequestT, endpoint, method string, organizationID *string) (*Stream[ChunkT], error) {
+	if client == nil {
+		client = DefaultClient()
+	}
+	req, body, err := newJSONRequest(ctx, client, request, endpoint, method, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	reservation, err := client.reserveRateLimit(ctx, requestOrNil(request), body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		client.updateRateLimit(reservation, nil, nil)
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errors.New("nil response received")
+	}
+	client.updateRateLimit(reservation, resp, nil)
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, newAPIError(resp, respBody)
+	}
+
+	stream := NewStream[ChunkT](resp.Body)
+	stream.response = resp
+	if reservation != nil {
+		// The usage of a stream is only known once it has been read,
+		// from the final chunk sent when stream_options.include_usage is set.
+		stream.onUsage = func(usage *ResponseUsage) {
+			if usage != nil {
+				client.rateLimiter.Update(reservation.model, reservation.tokens, usage, nil)
+			}
+		}
+	}
+	return stream, nil
+}
@@ -0,0 +1,72 @@
+package common_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Kardbord/gopenai/common"
+)
+
+func TestEventDecoder(t *testing.T) {
+	decoder := common.NewEventDecoder(strings.NewReader(
+		": keep-alive\r\n\r\nevent: message\r\ndata: line one\r\ndata:line two\r\n\r\nid: 7\ndata: {}",
+	))
+
+	event, err := decoder.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Event != "message" || string(event.Data) != "line one\nline two" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	event, err = decoder.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.ID != "7" || string(event.Data) != "{}" {
+		t.Fatalf("unexpected event: %+v", event)
+	}
+
+	if _, err = decoder.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	stream := common.NewStream[map[string]any](io.NopCloser(strings.NewReader(
+		"data: {\"id\":1}\n\ndata: {\"error\":{\"message\":\"boom\",\"type\":\"server_error\"}}\n\n",
+	)))
+	defer stream.Close()
+
+	if !stream.Next() {
+		t.Fatal("expected a chunk")
+	}
+	if stream.Next() {
+		t.Fatal("expected the stream to end")
+	}
+	respErr := new(common.ResponseError)
+	if !errors.As(stream.Err(), &respErr) || respErr.Message != "boom" {
+		t.Fatalf("unexpected error: %v", stream.Err())
+	}
+}
+
+func TestStreamCompatibleServer(t *testing.T) {
+	stream := common.NewStream[map[string]any](io.NopCloser(strings.NewReader(
+		": ping\r\ndata:{\"id\":1,\"extra\":true}\r\n\r\nevent: message\ndata: {\"object\":\"error\",\"message\":\"boom\",\"code\":500}",
+	)))
+	defer stream.Close()
+
+	if !stream.Next() || (*stream.Current())["id"] != float64(1) {
+		t.Fatalf("unexpected chunk: %v", stream.Current())
+	}
+	if stream.Next() {
+		t.Fatal("expected the stream to end")
+	}
+	respErr := new(common.ResponseError)
+	if !errors.As(stream.Err(), &respErr) || respErr.Message != "boom" || respErr.Code != "500" {
+		t.Fatalf("unexpected error: %v", stream.Err())
+	}
+}
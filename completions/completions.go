@@ -8,6 +8,7 @@
 package completions
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
@@ -63,7 +64,13 @@ type Request struct {
 	// Whether to stream back partial progress. If set, tokens will be sent as
 	// data-only server-sent events as they become available, with the stream
 	// terminated by a data: [DONE] message.
-	// Stream bool `json:"stream,omitempty"` TODO: Add streaming support
+	//
+	// This is set automatically by MakeStreamingRequest, and should not be
+	// set when using MakeRequest.
+	Stream bool `json:"stream,omitempty"`
+
+	// Options for streaming responses. Only set this when Stream is true.
+	StreamOptions *common.StreamOptions `json:"stream_options,omitempty"`
 
 	// Include the log probabilities on the logprobs most likely tokens, as well the
 	// chosen tokens. For example, if logprobs is 5, the API will return a list of
@@ -116,31 +123,75 @@ type Request struct {
 	Seed *int64 `json:"seed,omitempty"`
 }
 
+// EstimateTokens roughly estimates the number of tokens the request will
+// consume, including the maximum number of tokens it may generate.
+// It implements common.TokenEstimator, for rate limiting.
+func (r *Request) EstimateTokens() int64 {
+	var tokens int64
+	for _, p := range r.Prompt {
+		tokens += common.EstimateTokens(p)
+	}
+
+	n := uint64(1)
+	if r.N != nil && *r.N > 1 {
+		n = *r.N
+	}
+	if r.BestOf != nil && *r.BestOf > n {
+		n = *r.BestOf
+	}
+	prompts := uint64(len(r.Prompt))
+	if prompts == 0 {
+		prompts = 1
+	}
+	return tokens + int64(r.MaxTokens*n*prompts)
+}
+
+type LogProbs struct {
+	Tokens        []string             `json:"tokens"`
+	TokenLogProbs []float64            `json:"token_logprobs"`
+	TopLogProbs   []map[string]float64 `json:"top_logprobs"`
+	TextOffset    []uint64             `json:"text_offset"`
+}
+
+type Choice struct {
+	Text         string   `json:"text"`
+	Index        uint64   `json:"index"`
+	FinishReason string   `json:"finish_reason"`
+	LogProbs     LogProbs `json:"logprobs"`
+}
+
 // Response structure for the  completions API endpoint.
 type Response struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Created uint64 `json:"created"`
-	Model   string `json:"model"`
-	Choices []struct {
-		Text         string `json:"text"`
-		Index        uint64 `json:"index"`
-		FinishReason string `json:"finish_reason"`
-		LogProbs     struct {
-			Tokens        []string             `json:"tokens"`
-			TokenLogProbs []float64            `json:"token_logprobs"`
-			TopLogProbs   []map[string]float64 `json:"top_logprobs"`
-			TextOffset    []uint64             `json:"text_offset"`
-		} `json:"logprobs"`
-	} `json:"choices"`
+	ID                string                `json:"id"`
+	Object            string                `json:"object"`
+	Created           uint64                `json:"created"`
+	Model             string                `json:"model"`
+	Choices           []Choice              `json:"choices"`
 	SystemFingerprint string                `json:"system_fingerprint"`
 	Usage             common.ResponseUsage  `json:"usage"`
 	Error             *common.ResponseError `json:"error,omitempty"`
+
+	common.CacheMetadata
 }
 
 // Make a completions request.
 func MakeRequest(request *Request, organizationID *string) (*Response, error) {
-	r, err := common.MakeRequest[Request, Response](request, Endpoint, http.MethodPost, organizationID)
+	return MakeRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeRequest, except the request is made with the given context.
+func MakeRequestContext(ctx context.Context, request *Request, organizationID *string) (*Response, error) {
+	return MakeRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeRequest, except the request is sent using the given client.
+func MakeRequestWithClient(client *common.Client, request *Request, organizationID *string) (*Response, error) {
+	return MakeRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeRequestWithClient, except the request is made with the given context.
+func MakeRequestWithClientContext(ctx context.Context, client *common.Client, request *Request, organizationID *string) (*Response, error) {
+	r, err := common.MakeRequestWithClientContext[Request, Response](ctx, client, request, Endpoint, http.MethodPost, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -160,7 +211,22 @@ func MakeRequest(request *Request, organizationID *string) (*Response, error) {
 // Returns a moderations.ModerationFlagError prior to making the request if the
 // inputs are flagged by the moderations endpoint.
 func MakeModeratedRequest(request *Request, organizationID *string) (*Response, *moderations.Response, error) {
-	modr, err := moderations.MakeModeratedRequest(&moderations.Request{
+	return MakeModeratedRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeModeratedRequest, except the requests are made with the given context.
+func MakeModeratedRequestContext(ctx context.Context, request *Request, organizationID *string) (*Response, *moderations.Response, error) {
+	return MakeModeratedRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeModeratedRequest, except the requests are sent using the given client.
+func MakeModeratedRequestWithClient(client *common.Client, request *Request, organizationID *string) (*Response, *moderations.Response, error) {
+	return MakeModeratedRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeModeratedRequestWithClient, except the requests are made with the given context.
+func MakeModeratedRequestWithClientContext(ctx context.Context, client *common.Client, request *Request, organizationID *string) (*Response, *moderations.Response, error) {
+	modr, err := moderations.MakeModeratedRequestWithClientContext(ctx, client, &moderations.Request{
 		Input: request.Prompt,
 		Model: moderations.ModelLatest,
 	}, organizationID)
@@ -168,7 +234,7 @@ func MakeModeratedRequest(request *Request, organizationID *string) (*Response,
 		return nil, modr, err
 	}
 
-	r, err := MakeRequest(request, organizationID)
+	r, err := MakeRequestWithClientContext(ctx, client, request, organizationID)
 	if err != nil {
 		return nil, modr, err
 	}
@@ -0,0 +1,55 @@
+package completions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Kardbord/gopenai/common"
+)
+
+// A chunk of a streamed completions response. Each choice carries the
+// text generated since the previous chunk for the prompt at its Index,
+// along with the log probabilities of those tokens if Request.LogProbs
+// was set.
+type StreamChunk struct {
+	ID                string   `json:"id"`
+	Object            string   `json:"object"`
+	Created           uint64   `json:"created"`
+	Model             string   `json:"model"`
+	Choices           []Choice `json:"choices"`
+	SystemFingerprint string   `json:"system_fingerprint"`
+
+	// Only set on the final chunk, when StreamOptions.IncludeUsage is set.
+	Usage *common.ResponseUsage `json:"usage,omitempty"`
+}
+
+// A stream of completions chunks. See common.Stream.
+type Stream = common.Stream[StreamChunk]
+
+// Make a completions request, streaming the generated text as it becomes
+// available. request.Stream is set automatically. The caller must close
+// the returned stream.
+func MakeStreamingRequest(request *Request, organizationID *string) (*Stream, error) {
+	return MakeStreamingRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeStreamingRequest, except the request is made with the given context.
+func MakeStreamingRequestContext(ctx context.Context, request *Request, organizationID *string) (*Stream, error) {
+	return MakeStreamingRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeStreamingRequest, except the request is sent using the given client.
+func MakeStreamingRequestWithClient(client *common.Client, request *Request, organizationID *string) (*Stream, error) {
+	return MakeStreamingRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeStreamingRequestWithClient, except the request is made with the given context.
+func MakeStreamingRequestWithClientContext(ctx context.Context, client *common.Client, request *Request, organizationID *string) (*Stream, error) {
+	if request == nil {
+		return nil, errors.New("nil request provided")
+	}
+	streamRequest := *request
+	streamRequest.Stream = true
+	return common.MakeStreamingRequestWithClientContext[Request, StreamChunk](ctx, client, &streamRequest, Endpoint, http.MethodPost, organizationID)
+}
@@ -0,0 +1,52 @@
+package completions_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kardbord/gopenai/common"
+	"github.com/Kardbord/gopenai/completions"
+)
+
+func TestStreamingRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i, token := range []string{"Hello", " there", "!"} {
+			fmt.Fprintf(w, `data: {"id":"cmpl-1","object":"text_completion","choices":[{"text":%q,"index":%d,"logprobs":{"tokens":[%q],"token_logprobs":[-0.5]}}]}`+"\n\n", token, i%2, token)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := common.NewClient(common.WithAPIKey("key"), common.WithBaseURL(server.URL+"/v1"))
+	var logprobs uint64 = 1
+	stream, err := completions.MakeStreamingRequestWithClient(client, &completions.Request{
+		Model:    "gpt-3.5-turbo-instruct",
+		Prompt:   []string{"Say hello", "Say hello again"},
+		LogProbs: logprobs,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	text := map[uint64]string{}
+	tokens := 0
+	for stream.Next() {
+		for _, choice := range stream.Current().Choices {
+			text[choice.Index] += choice.Text
+			tokens += len(choice.LogProbs.TokenLogProbs)
+		}
+	}
+	if err = stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if text[0] != "Hello!" || text[1] != " there" {
+		t.Fatalf("unexpected text: %v", text)
+	}
+	if tokens != 3 {
+		t.Fatalf("expected 3 token logprobs, got %d", tokens)
+	}
+}
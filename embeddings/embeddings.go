@@ -7,6 +7,7 @@
 package embeddings
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
@@ -36,6 +37,16 @@ type Request struct {
 	EncodingFormat string `json:"encoding_format,omitempty"`
 }
 
+// EstimateTokens roughly estimates the number of tokens the request will
+// consume. It implements common.TokenEstimator, for rate limiting.
+func (r *Request) EstimateTokens() int64 {
+	var tokens int64
+	for _, input := range r.Input {
+		tokens += common.EstimateTokens(input)
+	}
+	return tokens
+}
+
 // Response structure for the embeddings API endpoint.
 type Response struct {
 	Object string `json:"object"`
@@ -47,10 +58,27 @@ type Response struct {
 	Model string                `json:"model"`
 	Usage common.ResponseUsage  `json:"usage"`
 	Error *common.ResponseError `json:"error,omitempty"`
+
+	common.CacheMetadata
 }
 
 func MakeRequest(request *Request, organizationID *string) (*Response, error) {
-	r, err := common.MakeRequest[Request, Response](request, Endpoint, http.MethodPost, organizationID)
+	return MakeRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeRequest, except the request is made with the given context.
+func MakeRequestContext(ctx context.Context, request *Request, organizationID *string) (*Response, error) {
+	return MakeRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeRequest, except the request is sent using the given client.
+func MakeRequestWithClient(client *common.Client, request *Request, organizationID *string) (*Response, error) {
+	return MakeRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeRequestWithClient, except the request is made with the given context.
+func MakeRequestWithClientContext(ctx context.Context, client *common.Client, request *Request, organizationID *string) (*Response, error) {
+	r, err := common.MakeRequestWithClientContext[Request, Response](ctx, client, request, Endpoint, http.MethodPost, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +98,22 @@ func MakeRequest(request *Request, organizationID *string) (*Response, error) {
 // Returns a moderations.ModerationFlagError prior to making the request if the
 // inputs are flagged by the moderations endpoint.
 func MakeModeratedRequest(request *Request, organizationID *string) (*Response, *moderations.Response, error) {
-	modr, err := moderations.MakeModeratedRequest(&moderations.Request{
+	return MakeModeratedRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeModeratedRequest, except the requests are made with the given context.
+func MakeModeratedRequestContext(ctx context.Context, request *Request, organizationID *string) (*Response, *moderations.Response, error) {
+	return MakeModeratedRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeModeratedRequest, except the requests are sent using the given client.
+func MakeModeratedRequestWithClient(client *common.Client, request *Request, organizationID *string) (*Response, *moderations.Response, error) {
+	return MakeModeratedRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeModeratedRequestWithClient, except the requests are made with the given context.
+func MakeModeratedRequestWithClientContext(ctx context.Context, client *common.Client, request *Request, organizationID *string) (*Response, *moderations.Response, error) {
+	modr, err := moderations.MakeModeratedRequestWithClientContext(ctx, client, &moderations.Request{
 		Input: request.Input,
 		Model: moderations.ModelLatest,
 	}, organizationID)
@@ -78,7 +121,7 @@ func MakeModeratedRequest(request *Request, organizationID *string) (*Response,
 		return nil, modr, err
 	}
 
-	r, err := MakeRequest(request, organizationID)
+	r, err := MakeRequestWithClientContext(ctx, client, request, organizationID)
 	if err != nil {
 		return nil, modr, err
 	}
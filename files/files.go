@@ -8,6 +8,7 @@ package files
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -39,7 +40,22 @@ type ListResponse struct {
 
 // Returns a list of files that belong to the user's organization.
 func MakeListRequest(organizationID *string) (*ListResponse, error) {
-	r, err := common.MakeRequest[any, ListResponse](nil, Endpoint, http.MethodGet, organizationID)
+	return MakeListRequestWithClientContext(context.Background(), common.DefaultClient(), organizationID)
+}
+
+// Same as MakeListRequest, except the request is made with the given context.
+func MakeListRequestContext(ctx context.Context, organizationID *string) (*ListResponse, error) {
+	return MakeListRequestWithClientContext(ctx, common.DefaultClient(), organizationID)
+}
+
+// Same as MakeListRequest, except the request is sent using the given client.
+func MakeListRequestWithClient(client *common.Client, organizationID *string) (*ListResponse, error) {
+	return MakeListRequestWithClientContext(context.Background(), client, organizationID)
+}
+
+// Same as MakeListRequestWithClient, except the request is made with the given context.
+func MakeListRequestWithClientContext(ctx context.Context, client *common.Client, organizationID *string) (*ListResponse, error) {
+	r, err := common.MakeRequestWithClientContext[any, ListResponse](ctx, client, nil, Endpoint, http.MethodGet, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -76,6 +92,21 @@ type UploadRequest struct {
 // Upload a file that contains document(s) to be used across various endpoints/features.
 // Currently, the size of all the files uploaded by one organization can be up to 1 GB.
 func MakeUploadRequest(request *UploadRequest, organizationID *string) (*UploadedFile, error) {
+	return MakeUploadRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeUploadRequest, except the request is made with the given context.
+func MakeUploadRequestContext(ctx context.Context, request *UploadRequest, organizationID *string) (*UploadedFile, error) {
+	return MakeUploadRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeUploadRequest, except the request is sent using the given client.
+func MakeUploadRequestWithClient(client *common.Client, request *UploadRequest, organizationID *string) (*UploadedFile, error) {
+	return MakeUploadRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeUploadRequestWithClient, except the request is made with the given context.
+func MakeUploadRequestWithClientContext(ctx context.Context, client *common.Client, request *UploadRequest, organizationID *string) (*UploadedFile, error) {
 	// Implementation largely taken from https://github.com/sashabaranov/go-gpt3/blob/1c20931ead68f5d7e7e04747720fac1ebd73d35c/files.go#L53-L117
 
 	buf := new(bytes.Buffer)
@@ -89,14 +120,14 @@ func MakeUploadRequest(request *UploadRequest, organizationID *string) (*Uploade
 	}
 
 	if len(request.Filepath) > 0 {
-		err := common.CreateFormFile("file", request.Filename, request.Filepath, writer)
+		err := common.CreateFormFileWithClientContext(ctx, client, "file", request.Filename, request.Filepath, writer)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	writer.Close()
-	r, err := common.MakeRequestWithForm[UploadedFile](buf, Endpoint, http.MethodPost, writer.FormDataContentType(), organizationID)
+	r, err := common.MakeRequestWithFormAndClientContext[UploadedFile](ctx, client, buf, Endpoint, http.MethodPost, writer.FormDataContentType(), organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -119,7 +150,22 @@ type DeleteResponse struct {
 
 // Delete an uploaded file.
 func MakeDeleteRequest(fileID string, organizationID *string) (*DeleteResponse, error) {
-	r, err := common.MakeRequest[any, DeleteResponse](nil, fmt.Sprintf("%s/%s", Endpoint, fileID), http.MethodDelete, organizationID)
+	return MakeDeleteRequestWithClientContext(context.Background(), common.DefaultClient(), fileID, organizationID)
+}
+
+// Same as MakeDeleteRequest, except the request is made with the given context.
+func MakeDeleteRequestContext(ctx context.Context, fileID string, organizationID *string) (*DeleteResponse, error) {
+	return MakeDeleteRequestWithClientContext(ctx, common.DefaultClient(), fileID, organizationID)
+}
+
+// Same as MakeDeleteRequest, except the request is sent using the given client.
+func MakeDeleteRequestWithClient(client *common.Client, fileID string, organizationID *string) (*DeleteResponse, error) {
+	return MakeDeleteRequestWithClientContext(context.Background(), client, fileID, organizationID)
+}
+
+// Same as MakeDeleteRequestWithClient, except the request is made with the given context.
+func MakeDeleteRequestWithClientContext(ctx context.Context, client *common.Client, fileID string, organizationID *string) (*DeleteResponse, error) {
+	r, err := common.MakeRequestWithClientContext[any, DeleteResponse](ctx, client, nil, fmt.Sprintf("%s/%s", Endpoint, fileID), http.MethodDelete, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -134,7 +180,22 @@ func MakeDeleteRequest(fileID string, organizationID *string) (*DeleteResponse,
 
 // Returns information about a specific file.
 func MakeRetrieveRequest(fileID string, organizationID *string) (*UploadedFile, error) {
-	r, err := common.MakeRequest[any, UploadedFile](nil, fmt.Sprintf("%s/%s", Endpoint, fileID), http.MethodGet, organizationID)
+	return MakeRetrieveRequestWithClientContext(context.Background(), common.DefaultClient(), fileID, organizationID)
+}
+
+// Same as MakeRetrieveRequest, except the request is made with the given context.
+func MakeRetrieveRequestContext(ctx context.Context, fileID string, organizationID *string) (*UploadedFile, error) {
+	return MakeRetrieveRequestWithClientContext(ctx, common.DefaultClient(), fileID, organizationID)
+}
+
+// Same as MakeRetrieveRequest, except the request is sent using the given client.
+func MakeRetrieveRequestWithClient(client *common.Client, fileID string, organizationID *string) (*UploadedFile, error) {
+	return MakeRetrieveRequestWithClientContext(context.Background(), client, fileID, organizationID)
+}
+
+// Same as MakeRetrieveRequestWithClient, except the request is made with the given context.
+func MakeRetrieveRequestWithClientContext(ctx context.Context, client *common.Client, fileID string, organizationID *string) (*UploadedFile, error) {
+	r, err := common.MakeRequestWithClientContext[any, UploadedFile](ctx, client, nil, fmt.Sprintf("%s/%s", Endpoint, fileID), http.MethodGet, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -151,12 +212,27 @@ func MakeRetrieveRequest(fileID string, organizationID *string) (*UploadedFile,
 // If "filepath" already exists and "overwrite" is false, an error will be returned.
 // If "filepath" already exists and "overwrite" is true, the existing file is truncated.
 func MakeRetrieveContentRequest(fileID, filepath string, overwrite bool, organizationID *string) error {
+	return MakeRetrieveContentRequestWithClientContext(context.Background(), common.DefaultClient(), fileID, filepath, overwrite, organizationID)
+}
+
+// Same as MakeRetrieveContentRequest, except the request is made with the given context.
+func MakeRetrieveContentRequestContext(ctx context.Context, fileID, filepath string, overwrite bool, organizationID *string) error {
+	return MakeRetrieveContentRequestWithClientContext(ctx, common.DefaultClient(), fileID, filepath, overwrite, organizationID)
+}
+
+// Same as MakeRetrieveContentRequest, except the request is sent using the given client.
+func MakeRetrieveContentRequestWithClient(client *common.Client, fileID, filepath string, overwrite bool, organizationID *string) error {
+	return MakeRetrieveContentRequestWithClientContext(context.Background(), client, fileID, filepath, overwrite, organizationID)
+}
+
+// Same as MakeRetrieveContentRequestWithClient, except the request is made with the given context.
+func MakeRetrieveContentRequestWithClientContext(ctx context.Context, client *common.Client, fileID, filepath string, overwrite bool, organizationID *string) error {
 	_, err := os.Stat(filepath)
 	if err == nil && !overwrite {
 		return os.ErrExist
 	}
 
-	respBody, err := MakeRetrieveContentRequestNoDisk(fileID, organizationID)
+	respBody, err := MakeRetrieveContentRequestNoDiskWithClientContext(ctx, client, fileID, organizationID)
 	if err != nil {
 		return err
 	}
@@ -177,31 +253,27 @@ func MakeRetrieveContentRequest(fileID, filepath string, overwrite bool, organiz
 
 // Retreives "fileID" from Open AI, and returns the bytes of the file.
 func MakeRetrieveContentRequestNoDisk(fileID string, organizationID *string) ([]byte, error) {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%s/content", Endpoint, fileID), nil)
-	if err != nil {
-		return nil, err
-	}
-	if req == nil {
-		return nil, errors.New("nil request created")
-	}
+	return MakeRetrieveContentRequestNoDiskWithClientContext(context.Background(), common.DefaultClient(), fileID, organizationID)
+}
 
-	common.SetRequestHeaders(req, "application/json", organizationID)
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	if resp == nil {
-		return nil, errors.New("nil response received")
-	}
-	defer resp.Body.Close()
+// Same as MakeRetrieveContentRequestNoDisk, except the request is made with the given context.
+func MakeRetrieveContentRequestNoDiskContext(ctx context.Context, fileID string, organizationID *string) ([]byte, error) {
+	return MakeRetrieveContentRequestNoDiskWithClientContext(ctx, common.DefaultClient(), fileID, organizationID)
+}
 
-	respBody, err := io.ReadAll(resp.Body)
+// Same as MakeRetrieveContentRequestNoDisk, except the request is sent using the given client.
+func MakeRetrieveContentRequestNoDiskWithClient(client *common.Client, fileID string, organizationID *string) ([]byte, error) {
+	return MakeRetrieveContentRequestNoDiskWithClientContext(context.Background(), client, fileID, organizationID)
+}
+
+// Same as MakeRetrieveContentRequestNoDiskWithClient, except the request is made with the given context.
+func MakeRetrieveContentRequestNoDiskWithClientContext(ctx context.Context, client *common.Client, fileID string, organizationID *string) ([]byte, error) {
+	r, err := common.MakeRequestWithClientContext[any, []byte](ctx, client, nil, fmt.Sprintf("%s/%s/content", Endpoint, fileID), http.MethodGet, organizationID)
 	if err != nil {
 		return nil, err
 	}
-	if respBody == nil {
-		return nil, errors.New("unable to parse response body")
+	if r == nil {
+		return nil, errors.New("nil response received")
 	}
-
-	return respBody, nil
+	return *r, nil
 }
@@ -7,8 +7,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/Kardbord/gopenai/authentication"
 	"github.com/Kardbord/gopenai/files"
+	"github.com/Kardbord/gopenai/openaitest"
 	_ "github.com/joho/godotenv/autoload"
 )
 
@@ -16,9 +16,8 @@ const OpenAITokenEnv = "OPENAI_API_KEY"
 const file = "./test_files/testdata.jsonl"
 const file2 = "retrieveddata2.jsonl"
 
-func init() {
-	key := os.Getenv(OpenAITokenEnv)
-	authentication.SetAPIKey(key)
+func TestMain(m *testing.M) {
+	os.Exit(openaitest.MainWithCassette(m, "testdata/cassette.json"))
 }
 
 func list(t *testing.T) error {
@@ -87,7 +86,12 @@ func TestFiles(t *testing.T) {
 		return
 	}
 
-	const sleepDuration = 30
+	// Files are processed immediately by the mock server,
+	// and in the recorded cassette.
+	sleepDuration := 30
+	if len(os.Getenv(OpenAITokenEnv)) == 0 {
+		sleepDuration = 0
+	}
 	for i := 0; i < sleepDuration; i++ {
 		fmt.Printf("Sleeping to allow the file to process %d/%ds\n", i, sleepDuration)
 		time.Sleep(time.Second)
@@ -8,6 +8,7 @@
 package finetuning
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -112,7 +113,22 @@ type FineTune struct {
 //
 // [Learn more about Fine-tuning]: https://beta.openai.com/docs/guides/fine-tuning
 func MakeCreationRequest(request *CreationRequest, organizationID *string) (*FineTune, error) {
-	r, err := common.MakeRequest[CreationRequest, FineTune](request, Endpoint, http.MethodPost, organizationID)
+	return MakeCreationRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeCreationRequest, except the request is made with the given context.
+func MakeCreationRequestContext(ctx context.Context, request *CreationRequest, organizationID *string) (*FineTune, error) {
+	return MakeCreationRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeCreationRequest, except the request is sent using the given client.
+func MakeCreationRequestWithClient(client *common.Client, request *CreationRequest, organizationID *string) (*FineTune, error) {
+	return MakeCreationRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeCreationRequestWithClient, except the request is made with the given context.
+func MakeCreationRequestWithClientContext(ctx context.Context, client *common.Client, request *CreationRequest, organizationID *string) (*FineTune, error) {
+	r, err := common.MakeRequestWithClientContext[CreationRequest, FineTune](ctx, client, request, Endpoint, http.MethodPost, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -134,6 +150,21 @@ type ListResponse struct {
 
 // List your organization's fine-tuning jobs
 func MakeListRequest(limit *uint64, after, organizationID *string) (*ListResponse, error) {
+	return MakeListRequestWithClientContext(context.Background(), common.DefaultClient(), limit, after, organizationID)
+}
+
+// Same as MakeListRequest, except the request is made with the given context.
+func MakeListRequestContext(ctx context.Context, limit *uint64, after, organizationID *string) (*ListResponse, error) {
+	return MakeListRequestWithClientContext(ctx, common.DefaultClient(), limit, after, organizationID)
+}
+
+// Same as MakeListRequest, except the request is sent using the given client.
+func MakeListRequestWithClient(client *common.Client, limit *uint64, after, organizationID *string) (*ListResponse, error) {
+	return MakeListRequestWithClientContext(context.Background(), client, limit, after, organizationID)
+}
+
+// Same as MakeListRequestWithClient, except the request is made with the given context.
+func MakeListRequestWithClientContext(ctx context.Context, client *common.Client, limit *uint64, after, organizationID *string) (*ListResponse, error) {
 	endpoint := Endpoint
 	if after != nil && limit != nil {
 		endpoint = fmt.Sprintf("%s?after=%s&limit=%d", endpoint, *after, *limit)
@@ -142,7 +173,7 @@ func MakeListRequest(limit *uint64, after, organizationID *string) (*ListRespons
 	} else if limit != nil {
 		endpoint = fmt.Sprintf("%s?limit=%d", endpoint, *limit)
 	}
-	r, err := common.MakeRequest[any, ListResponse](nil, endpoint, http.MethodGet, organizationID)
+	r, err := common.MakeRequestWithClientContext[any, ListResponse](ctx, client, nil, endpoint, http.MethodGet, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -157,7 +188,22 @@ func MakeListRequest(limit *uint64, after, organizationID *string) (*ListRespons
 
 // Gets info about the fine-tune job.
 func MakeRetrieveRequest(fineTuneID string, organizationID *string) (*FineTune, error) {
-	r, err := common.MakeRequest[any, FineTune](nil, fmt.Sprintf("%s/%s", Endpoint, fineTuneID), http.MethodGet, organizationID)
+	return MakeRetrieveRequestWithClientContext(context.Background(), common.DefaultClient(), fineTuneID, organizationID)
+}
+
+// Same as MakeRetrieveRequest, except the request is made with the given context.
+func MakeRetrieveRequestContext(ctx context.Context, fineTuneID string, organizationID *string) (*FineTune, error) {
+	return MakeRetrieveRequestWithClientContext(ctx, common.DefaultClient(), fineTuneID, organizationID)
+}
+
+// Same as MakeRetrieveRequest, except the request is sent using the given client.
+func MakeRetrieveRequestWithClient(client *common.Client, fineTuneID string, organizationID *string) (*FineTune, error) {
+	return MakeRetrieveRequestWithClientContext(context.Background(), client, fineTuneID, organizationID)
+}
+
+// Same as MakeRetrieveRequestWithClient, except the request is made with the given context.
+func MakeRetrieveRequestWithClientContext(ctx context.Context, client *common.Client, fineTuneID string, organizationID *string) (*FineTune, error) {
+	r, err := common.MakeRequestWithClientContext[any, FineTune](ctx, client, nil, fmt.Sprintf("%s/%s", Endpoint, fineTuneID), http.MethodGet, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -172,7 +218,22 @@ func MakeRetrieveRequest(fineTuneID string, organizationID *string) (*FineTune,
 
 // Immediately cancel a fine-tune job.
 func MakeCancelRequest(fineTuneID string, organizationID *string) (*FineTune, error) {
-	r, err := common.MakeRequest[any, FineTune](nil, fmt.Sprintf("%s/%s/cancel", Endpoint, fineTuneID), http.MethodPost, organizationID)
+	return MakeCancelRequestWithClientContext(context.Background(), common.DefaultClient(), fineTuneID, organizationID)
+}
+
+// Same as MakeCancelRequest, except the request is made with the given context.
+func MakeCancelRequestContext(ctx context.Context, fineTuneID string, organizationID *string) (*FineTune, error) {
+	return MakeCancelRequestWithClientContext(ctx, common.DefaultClient(), fineTuneID, organizationID)
+}
+
+// Same as MakeCancelRequest, except the request is sent using the given client.
+func MakeCancelRequestWithClient(client *common.Client, fineTuneID string, organizationID *string) (*FineTune, error) {
+	return MakeCancelRequestWithClientContext(context.Background(), client, fineTuneID, organizationID)
+}
+
+// Same as MakeCancelRequestWithClient, except the request is made with the given context.
+func MakeCancelRequestWithClientContext(ctx context.Context, client *common.Client, fineTuneID string, organizationID *string) (*FineTune, error) {
+	r, err := common.MakeRequestWithClientContext[any, FineTune](ctx, client, nil, fmt.Sprintf("%s/%s/cancel", Endpoint, fineTuneID), http.MethodPost, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -194,6 +255,21 @@ type ListEventsResponse struct {
 
 // Get fine-grained status updates for a fine-tune job.
 func MakeListEventsRequest(fineTuneID string, limit *uint64, after, organizationID *string) (*ListEventsResponse, error) {
+	return MakeListEventsRequestWithClientContext(context.Background(), common.DefaultClient(), fineTuneID, limit, after, organizationID)
+}
+
+// Same as MakeListEventsRequest, except the request is made with the given context.
+func MakeListEventsRequestContext(ctx context.Context, fineTuneID string, limit *uint64, after, organizationID *string) (*ListEventsResponse, error) {
+	return MakeListEventsRequestWithClientContext(ctx, common.DefaultClient(), fineTuneID, limit, after, organizationID)
+}
+
+// Same as MakeListEventsRequest, except the request is sent using the given client.
+func MakeListEventsRequestWithClient(client *common.Client, fineTuneID string, limit *uint64, after, organizationID *string) (*ListEventsResponse, error) {
+	return MakeListEventsRequestWithClientContext(context.Background(), client, fineTuneID, limit, after, organizationID)
+}
+
+// Same as MakeListEventsRequestWithClient, except the request is made with the given context.
+func MakeListEventsRequestWithClientContext(ctx context.Context, client *common.Client, fineTuneID string, limit *uint64, after, organizationID *string) (*ListEventsResponse, error) {
 	// TODO: support streaming: https://beta.openai.com/docs/api-reference/fine-tunes/events#fine-tunes/events-stream
 
 	endpoint := fmt.Sprintf("%s/%s/events", Endpoint, fineTuneID)
@@ -205,7 +281,7 @@ func MakeListEventsRequest(fineTuneID string, limit *uint64, after, organization
 		endpoint = fmt.Sprintf("%s?limit=%d", endpoint, *limit)
 	}
 
-	r, err := common.MakeRequest[any, ListEventsResponse](nil, endpoint, http.MethodGet, organizationID)
+	r, err := common.MakeRequestWithClientContext[any, ListEventsResponse](ctx, client, nil, endpoint, http.MethodGet, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -227,7 +303,22 @@ type DeleteResponse struct {
 
 // Delete a fine-tuned model. You must have the Owner role in your organization.
 func MakeDeleteRequest(fineTuneModel string, organizationID *string) (*DeleteResponse, error) {
-	r, err := common.MakeRequest[any, DeleteResponse](nil, fmt.Sprintf("%s/%s", models.Endpoint, fineTuneModel), http.MethodDelete, organizationID)
+	return MakeDeleteRequestWithClientContext(context.Background(), common.DefaultClient(), fineTuneModel, organizationID)
+}
+
+// Same as MakeDeleteRequest, except the request is made with the given context.
+func MakeDeleteRequestContext(ctx context.Context, fineTuneModel string, organizationID *string) (*DeleteResponse, error) {
+	return MakeDeleteRequestWithClientContext(ctx, common.DefaultClient(), fineTuneModel, organizationID)
+}
+
+// Same as MakeDeleteRequest, except the request is sent using the given client.
+func MakeDeleteRequestWithClient(client *common.Client, fineTuneModel string, organizationID *string) (*DeleteResponse, error) {
+	return MakeDeleteRequestWithClientContext(context.Background(), client, fineTuneModel, organizationID)
+}
+
+// Same as MakeDeleteRequestWithClient, except the request is made with the given context.
+func MakeDeleteRequestWithClientContext(ctx context.Context, client *common.Client, fineTuneModel string, organizationID *string) (*DeleteResponse, error) {
+	r, err := common.MakeRequestWithClientContext[any, DeleteResponse](ctx, client, nil, fmt.Sprintf("%s/%s", models.Endpoint, fineTuneModel), http.MethodDelete, organizationID)
 	if err != nil {
 		return nil, err
 	}
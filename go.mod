@@ -1,5 +1,5 @@
 module github.com/Kardbord/gopenai
 
-go 1.19
+go 1.21
 
 require github.com/joho/godotenv v1.5.1
@@ -9,6 +9,7 @@ package images
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"mime/multipart"
 	"net/http"
@@ -110,7 +111,22 @@ type CreationRequest struct {
 
 // Creates an image given a prompt.
 func MakeCreationRequest(request *CreationRequest, organizationID *string) (*Response, error) {
-	r, err := common.MakeRequest[CreationRequest, Response](request, CreateEndpoint, http.MethodPost, organizationID)
+	return MakeCreationRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeCreationRequest, except the request is made with the given context.
+func MakeCreationRequestContext(ctx context.Context, request *CreationRequest, organizationID *string) (*Response, error) {
+	return MakeCreationRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeCreationRequest, except the request is sent using the given client.
+func MakeCreationRequestWithClient(client *common.Client, request *CreationRequest, organizationID *string) (*Response, error) {
+	return MakeCreationRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeCreationRequestWithClient, except the request is made with the given context.
+func MakeCreationRequestWithClientContext(ctx context.Context, client *common.Client, request *CreationRequest, organizationID *string) (*Response, error) {
+	r, err := common.MakeRequestWithClientContext[CreationRequest, Response](ctx, client, request, CreateEndpoint, http.MethodPost, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -130,7 +146,22 @@ func MakeCreationRequest(request *CreationRequest, organizationID *string) (*Res
 // Returns a moderations.ModerationFlagError prior to making the request if the
 // inputs are flagged by the moderations endpoint.
 func MakeModeratedCreationRequest(request *CreationRequest, organizationID *string) (*Response, *moderations.Response, error) {
-	modr, err := moderations.MakeModeratedRequest(&moderations.Request{
+	return MakeModeratedCreationRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeModeratedCreationRequest, except the requests are made with the given context.
+func MakeModeratedCreationRequestContext(ctx context.Context, request *CreationRequest, organizationID *string) (*Response, *moderations.Response, error) {
+	return MakeModeratedCreationRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeModeratedCreationRequest, except the requests are sent using the given client.
+func MakeModeratedCreationRequestWithClient(client *common.Client, request *CreationRequest, organizationID *string) (*Response, *moderations.Response, error) {
+	return MakeModeratedCreationRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeModeratedCreationRequestWithClient, except the requests are made with the given context.
+func MakeModeratedCreationRequestWithClientContext(ctx context.Context, client *common.Client, request *CreationRequest, organizationID *string) (*Response, *moderations.Response, error) {
+	modr, err := moderations.MakeModeratedRequestWithClientContext(ctx, client, &moderations.Request{
 		Input: []string{request.Prompt},
 		Model: moderations.ModelLatest,
 	}, organizationID)
@@ -138,7 +169,7 @@ func MakeModeratedCreationRequest(request *CreationRequest, organizationID *stri
 		return nil, modr, err
 	}
 
-	r, err := MakeCreationRequest(request, organizationID)
+	r, err := MakeCreationRequestWithClientContext(ctx, client, request, organizationID)
 	if err != nil {
 		return nil, modr, err
 	}
@@ -186,6 +217,21 @@ type EditRequest struct {
 
 // Creates an edited or extended image given an original image and a prompt.
 func MakeEditRequest(request *EditRequest, organizationID *string) (*Response, error) {
+	return MakeEditRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeEditRequest, except the request is made with the given context.
+func MakeEditRequestContext(ctx context.Context, request *EditRequest, organizationID *string) (*Response, error) {
+	return MakeEditRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeEditRequest, except the request is sent using the given client.
+func MakeEditRequestWithClient(client *common.Client, request *EditRequest, organizationID *string) (*Response, error) {
+	return MakeEditRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeEditRequestWithClient, except the request is made with the given context.
+func MakeEditRequestWithClientContext(ctx context.Context, client *common.Client, request *EditRequest, organizationID *string) (*Response, error) {
 	if request == nil {
 		return nil, errors.New("nil request provided")
 	}
@@ -238,21 +284,21 @@ func MakeEditRequest(request *EditRequest, organizationID *string) (*Response, e
 	}
 
 	if len(request.Image) > 0 {
-		err = common.CreateFormFile("image", request.ImageName, request.Image, writer)
+		err = common.CreateFormFileWithClientContext(ctx, client, "image", request.ImageName, request.Image, writer)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	if len(request.Mask) > 0 {
-		err = common.CreateFormFile("mask", request.MaskName, request.Mask, writer)
+		err = common.CreateFormFileWithClientContext(ctx, client, "mask", request.MaskName, request.Mask, writer)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	writer.Close()
-	r, err := common.MakeRequestWithForm[Response](buf, EditEndpoint, http.MethodPost, writer.FormDataContentType(), organizationID)
+	r, err := common.MakeRequestWithFormAndClientContext[Response](ctx, client, buf, EditEndpoint, http.MethodPost, writer.FormDataContentType(), organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -272,7 +318,22 @@ func MakeEditRequest(request *EditRequest, organizationID *string) (*Response, e
 // Returns a moderations.ModerationFlagError prior to making the request if the
 // inputs are flagged by the moderations endpoint.
 func MakeModeratedRequest(request *EditRequest, organizationID *string) (*Response, *moderations.Response, error) {
-	modr, err := moderations.MakeModeratedRequest(&moderations.Request{
+	return MakeModeratedRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeModeratedRequest, except the requests are made with the given context.
+func MakeModeratedRequestContext(ctx context.Context, request *EditRequest, organizationID *string) (*Response, *moderations.Response, error) {
+	return MakeModeratedRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeModeratedRequest, except the requests are sent using the given client.
+func MakeModeratedRequestWithClient(client *common.Client, request *EditRequest, organizationID *string) (*Response, *moderations.Response, error) {
+	return MakeModeratedRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeModeratedRequestWithClient, except the requests are made with the given context.
+func MakeModeratedRequestWithClientContext(ctx context.Context, client *common.Client, request *EditRequest, organizationID *string) (*Response, *moderations.Response, error) {
+	modr, err := moderations.MakeModeratedRequestWithClientContext(ctx, client, &moderations.Request{
 		Input: []string{request.Prompt},
 		Model: moderations.ModelLatest,
 	}, organizationID)
@@ -280,7 +341,7 @@ func MakeModeratedRequest(request *EditRequest, organizationID *string) (*Respon
 		return nil, modr, err
 	}
 
-	r, err := MakeEditRequest(request, organizationID)
+	r, err := MakeEditRequestWithClientContext(ctx, client, request, organizationID)
 	if err != nil {
 		return nil, modr, err
 	}
@@ -314,6 +375,21 @@ type VariationRequest struct {
 
 // Creates a variation of a given image.
 func MakeVariationRequest(request *VariationRequest, organizationID *string) (*Response, error) {
+	return MakeVariationRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeVariationRequest, except the request is made with the given context.
+func MakeVariationRequestContext(ctx context.Context, request *VariationRequest, organizationID *string) (*Response, error) {
+	return MakeVariationRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeVariationRequest, except the request is sent using the given client.
+func MakeVariationRequestWithClient(client *common.Client, request *VariationRequest, organizationID *string) (*Response, error) {
+	return MakeVariationRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeVariationRequestWithClient, except the request is made with the given context.
+func MakeVariationRequestWithClientContext(ctx context.Context, client *common.Client, request *VariationRequest, organizationID *string) (*Response, error) {
 	if request == nil {
 		return nil, errors.New("nil request provided")
 	}
@@ -352,7 +428,7 @@ func MakeVariationRequest(request *VariationRequest, organizationID *string) (*R
 	}
 
 	if len(request.Image) > 0 {
-		err = common.CreateFormFile("image", request.ImageName, request.Image, writer)
+		err = common.CreateFormFileWithClientContext(ctx, client, "image", request.ImageName, request.Image, writer)
 		if err != nil {
 			return nil, err
 		}
@@ -366,7 +442,7 @@ func MakeVariationRequest(request *VariationRequest, organizationID *string) (*R
 	}
 
 	writer.Close()
-	r, err := common.MakeRequestWithForm[Response](buf, VariationEndpoint, http.MethodPost, writer.FormDataContentType(), organizationID)
+	r, err := common.MakeRequestWithFormAndClientContext[Response](ctx, client, buf, VariationEndpoint, http.MethodPost, writer.FormDataContentType(), organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -6,16 +6,15 @@ import (
 	"os"
 	"testing"
 
-	"github.com/Kardbord/gopenai/authentication"
 	"github.com/Kardbord/gopenai/images"
+	"github.com/Kardbord/gopenai/openaitest"
 	_ "github.com/joho/godotenv/autoload"
 )
 
 const OpenAITokenEnv = "OPENAI_API_KEY"
 
-func init() {
-	key := os.Getenv(OpenAITokenEnv)
-	authentication.SetAPIKey(key)
+func TestMain(m *testing.M) {
+	os.Exit(openaitest.Main(m))
 }
 
 func create(model, size string) (*images.Response, error) {
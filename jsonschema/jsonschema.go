@@ -0,0 +1,423 @@
+// Package jsonschema generates [JSON Schema] from Go types, for use as
+// the parameters of a chat tool or as a structured output response format.
+//
+// The generated schemas are compatible with the [strict mode] of the
+// OpenAI API, which requires every property to be listed as required and
+// additional properties to be disallowed. Fields which may be omitted, such
+// as pointers, are made nullable instead.
+//
+// Schemas are derived from struct fields as follows:
+//
+//   - The json struct tag sets the property name. Fields tagged with "-"
+//     and unexported fields are skipped, and the fields of embedded structs
+//     are promoted, as with encoding/json.
+//   - The description struct tag sets the description of the property.
+//   - The enum struct tag sets a comma-separated list of allowed values.
+//   - Pointer fields are nullable.
+//   - Slices and arrays are arrays, except for []byte which is a string.
+//   - Maps with string keys are objects whose additional properties match
+//     the element type. Note that strict mode does not support such maps.
+//   - time.Time is a string with the date-time format.
+//   - Recursive types are described with $defs and $ref.
+//
+// [JSON Schema]: https://json-schema.org/
+// [strict mode]: https://platform.openai.com/docs/guides/structured-outputs#supported-schemas
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+	TypeNull    = "null"
+)
+
+// A JSON Schema.
+type Schema struct {
+	// The type of the value, which may be a list of types such as
+	// ["string", "null"]. Left empty to accept any type.
+	Type Types `json:"type,omitempty"`
+
+	Description string `json:"description,omitempty"`
+
+	// The values the value is restricted to.
+	Enum []any `json:"enum,omitempty"`
+
+	// The format of a string value, such as "date-time".
+	Format string `json:"format,omitempty"`
+
+	// The properties of an object, in order.
+	Properties Properties `json:"properties,omitempty"`
+
+	// The names of the properties of an object which must be present.
+	Required []string `json:"required,omitempty"`
+
+	// Either false, to disallow properties not listed in Properties,
+	// or a *Schema which any additional properties must match.
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+
+	// The schema of the elements of an array.
+	Items *Schema `json:"items,omitempty"`
+
+	// A reference to another schema, such as "#/$defs/Name".
+	Ref string `json:"$ref,omitempty"`
+
+	// A list of schemas, any of which the value may match.
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+
+	// Schemas referenced by Ref.
+	Defs map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// A list of JSON Schema types. A list containing a single type
+// is encoded as a plain string.
+type Types []string
+
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	if len(data) != 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*t = Types{s}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// A property of an object schema.
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// The properties of an object schema. Unlike a map, Properties retain
+// their order, which the model follows when generating output.
+type Properties []Property
+
+// Get returns the schema of the named property, or nil if there is none.
+func (p Properties) Get(name string) *Schema {
+	for _, prop := range p {
+		if prop.Name == name {
+			return prop.Schema
+		}
+	}
+	return nil
+}
+
+func (p Properties) MarshalJSON() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, prop := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(prop.Name)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := json.Marshal(prop.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(schema)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (p *Properties) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := decoder.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return errors.New("properties must be an object")
+	}
+	props := Properties{}
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		schema := &Schema{}
+		if err = decoder.Decode(schema); err != nil {
+			return err
+		}
+		props = append(props, Property{Name: tok.(string), Schema: schema})
+	}
+	*p = props
+	return nil
+}
+
+// Generate returns the schema of T, which is typically a struct.
+func Generate[T any]() (*Schema, error) {
+	return For(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// MustGenerate is like Generate, but panics if the schema cannot be
+// generated. It simplifies the initialization of global variables.
+func MustGenerate[T any]() *Schema {
+	s, err := Generate[T]()
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// For returns the schema of the given type.
+func For(t reflect.Type) (*Schema, error) {
+	g := generator{
+		root:      t,
+		visiting:  map[reflect.Type]bool{},
+		recursive: map[reflect.Type]bool{},
+		defs:      map[string]*Schema{},
+	}
+	s, err := g.schema(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.defs) != 0 {
+		s.Defs = g.defs
+	}
+	return s, nil
+}
+
+type generator struct {
+	root      reflect.Type
+	visiting  map[reflect.Type]bool
+	recursive map[reflect.Type]bool
+	defs      map[string]*Schema
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+func (g *generator) schema(t reflect.Type) (*Schema, error) {
+	switch t {
+	case timeType:
+		return &Schema{Type: Types{TypeString}, Format: "date-time"}, nil
+	case rawMessageType:
+		return &Schema{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return nullable(s), nil
+	case reflect.Bool:
+		return &Schema{Type: Types{TypeBoolean}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: Types{TypeInteger}}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{TypeNumber}}, nil
+	case reflect.String:
+		return &Schema{Type: Types{TypeString}}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// Encoded as base64 by encoding/json.
+			return &Schema{Type: Types{TypeString}}, nil
+		}
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: Types{TypeArray}, Items: items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		elem, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: Types{TypeObject}, AdditionalProperties: elem}, nil
+	case reflect.Struct:
+		return g.structSchema(t)
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+func (g *generator) structSchema(t reflect.Type) (*Schema, error) {
+	if g.visiting[t] {
+		g.recursive[t] = true
+		return g.ref(t), nil
+	}
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+
+	s := &Schema{
+		Type:                 Types{TypeObject},
+		Properties:           Properties{},
+		Required:             []string{},
+		AdditionalProperties: false,
+	}
+	if err := g.addFields(s, t); err != nil {
+		return nil, err
+	}
+
+	if g.recursive[t] && t != g.root {
+		g.defs[defName(t)] = s
+		return g.ref(t), nil
+	}
+	return s, nil
+}
+
+func (g *generator) ref(t reflect.Type) *Schema {
+	if t == g.root {
+		return &Schema{Ref: "#"}
+	}
+	return &Schema{Ref: "#/$defs/" + defName(t)}
+}
+
+func defName(t reflect.Type) string {
+	if len(t.Name()) != 0 {
+		return t.Name()
+	}
+	return strings.NewReplacer(" ", "", "{", "_", "}", "_", ";", "_").Replace(t.String())
+}
+
+func (g *generator) addFields(s *Schema, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && len(name) == 0 {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := g.addFields(s, ft); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+
+		prop, err := g.schema(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if desc := field.Tag.Get("description"); len(desc) != 0 {
+			prop = withDescription(prop, desc)
+		}
+		if enum, ok := field.Tag.Lookup("enum"); ok {
+			if prop, err = withEnum(prop, field.Type, enum); err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+		}
+
+		s.Properties = append(s.Properties, Property{Name: name, Schema: prop})
+		s.Required = append(s.Required, name)
+	}
+	return nil
+}
+
+// nullable returns a schema which accepts null as well as any value matching s.
+func nullable(s *Schema) *Schema {
+	if len(s.Ref) != 0 {
+		return &Schema{AnyOf: []*Schema{s, {Type: Types{TypeNull}}}}
+	}
+	if len(s.Type) == 0 || len(s.AnyOf) != 0 {
+		return s
+	}
+	n := *s
+	n.Type = append(append(Types{}, s.Type...), TypeNull)
+	if s.Enum != nil {
+		n.Enum = append(append([]any{}, s.Enum...), nil)
+	}
+	return &n
+}
+
+func withDescription(s *Schema, description string) *Schema {
+	d := *s
+	d.Description = description
+	return &d
+}
+
+// withEnum returns a copy of s restricted to the comma-separated values in
+// enum, which are parsed according to the kind of t.
+func withEnum(s *Schema, t reflect.Type, enum string) (*Schema, error) {
+	isNullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		isNullable = true
+	}
+
+	values := []any{}
+	for _, v := range strings.Split(enum, ",") {
+		v = strings.TrimSpace(v)
+		switch t.Kind() {
+		case reflect.String:
+			values = append(values, v)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			i, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid enum value %q: %w", v, err)
+			}
+			values = append(values, i)
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid enum value %q: %w", v, err)
+			}
+			values = append(values, f)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid enum value %q: %w", v, err)
+			}
+			values = append(values, b)
+		default:
+			return nil, fmt.Errorf("enum is not supported for type %s", t)
+		}
+	}
+	if isNullable {
+		values = append(values, nil)
+	}
+
+	e := *s
+	e.Enum = values
+	return &e, nil
+}
@@ -0,0 +1,128 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Kardbord/gopenai/jsonschema"
+)
+
+type Location struct {
+	City    string `json:"city" description:"The name of the city."`
+	Country string `json:"country,omitempty"`
+}
+
+type Base struct {
+	ID int64 `json:"id"`
+}
+
+type Weather struct {
+	Base
+	Location    Location          `json:"location"`
+	Unit        string            `json:"unit" enum:"celsius,fahrenheit"`
+	Days        *int              `json:"days" enum:"1,3,7"`
+	Temperature []float64         `json:"temperature"`
+	Sunny       *bool             `json:"sunny"`
+	Tags        map[string]string `json:"tags"`
+	Updated     time.Time         `json:"updated"`
+	Raw         []byte            `json:"raw"`
+	Ignored     string            `json:"-"`
+	unexported  string
+	NoTag       string
+}
+
+func TestGenerate(t *testing.T) {
+	s, err := jsonschema.Generate[Weather]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"type":"object","properties":{` +
+		`"id":{"type":"integer"},` +
+		`"location":{"type":"object","properties":{"city":{"type":"string","description":"The name of the city."},"country":{"type":"string"}},"required":["city","country"],"additionalProperties":false},` +
+		`"unit":{"type":"string","enum":["celsius","fahrenheit"]},` +
+		`"days":{"type":["integer","null"],"enum":[1,3,7,null]},` +
+		`"temperature":{"type":"array","items":{"type":"number"}},` +
+		`"sunny":{"type":["boolean","null"]},` +
+		`"tags":{"type":"object","additionalProperties":{"type":"string"}},` +
+		`"updated":{"type":"string","format":"date-time"},` +
+		`"raw":{"type":"string"},` +
+		`"NoTag":{"type":"string"}},` +
+		`"required":["id","location","unit","days","temperature","sunny","tags","updated","raw","NoTag"],` +
+		`"additionalProperties":false}`
+	if string(data) != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, data)
+	}
+
+	// Property order must survive a round trip.
+	decoded := jsonschema.Schema{}
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	roundTrip, err := json.Marshal(&decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(roundTrip) != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, roundTrip)
+	}
+}
+
+type Tree struct {
+	Value    string `json:"value"`
+	Children []Tree `json:"children"`
+}
+
+type Node struct {
+	Name string `json:"name"`
+	Next *Node  `json:"next"`
+}
+
+type List struct {
+	Head *Node `json:"head"`
+}
+
+func TestGenerateRecursive(t *testing.T) {
+	s, err := jsonschema.Generate[Tree]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref := s.Properties.Get("children").Items.Ref; ref != "#" {
+		t.Fatalf("expected root reference, got %q", ref)
+	}
+
+	s, err = jsonschema.Generate[List]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := s.Properties.Get("head")
+	if len(head.AnyOf) != 2 || head.AnyOf[0].Ref != "#/$defs/Node" || !reflect.DeepEqual(head.AnyOf[1].Type, jsonschema.Types{jsonschema.TypeNull}) {
+		t.Fatalf("unexpected schema for head: %+v", head)
+	}
+	node := s.Defs["Node"]
+	if node == nil || node.Properties.Get("next").AnyOf[0].Ref != "#/$defs/Node" {
+		t.Fatalf("unexpected definitions: %+v", s.Defs)
+	}
+}
+
+func TestGenerateUnsupported(t *testing.T) {
+	if _, err := jsonschema.Generate[map[int]string](); err == nil {
+		t.Fatal("expected an error for a map with integer keys")
+	}
+	if _, err := jsonschema.Generate[struct {
+		C chan int `json:"c"`
+	}](); err == nil {
+		t.Fatal("expected an error for a channel")
+	}
+	if _, err := jsonschema.Generate[struct {
+		Days int `json:"days" enum:"one"`
+	}](); err == nil {
+		t.Fatal("expected an error for an invalid enum value")
+	}
+}
@@ -9,6 +9,7 @@
 package models
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
@@ -50,7 +51,22 @@ type ListModelsResponse struct {
 
 // Lists the currently available models, and provides basic information about each one such as the owner and availability.
 func MakeListModelsRequest(organizationID *string) (*ListModelsResponse, error) {
-	r, err := common.MakeRequest[any, ListModelsResponse](nil, Endpoint, http.MethodGet, organizationID)
+	return MakeListModelsRequestWithClientContext(context.Background(), common.DefaultClient(), organizationID)
+}
+
+// Same as MakeListModelsRequest, except the request is made with the given context.
+func MakeListModelsRequestContext(ctx context.Context, organizationID *string) (*ListModelsResponse, error) {
+	return MakeListModelsRequestWithClientContext(ctx, common.DefaultClient(), organizationID)
+}
+
+// Same as MakeListModelsRequest, except the request is sent using the given client.
+func MakeListModelsRequestWithClient(client *common.Client, organizationID *string) (*ListModelsResponse, error) {
+	return MakeListModelsRequestWithClientContext(context.Background(), client, organizationID)
+}
+
+// Same as MakeListModelsRequestWithClient, except the request is made with the given context.
+func MakeListModelsRequestWithClientContext(ctx context.Context, client *common.Client, organizationID *string) (*ListModelsResponse, error) {
+	r, err := common.MakeRequestWithClientContext[any, ListModelsResponse](ctx, client, nil, Endpoint, http.MethodGet, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -68,7 +84,22 @@ func MakeListModelsRequest(organizationID *string) (*ListModelsResponse, error)
 
 // Retrieves a model instance, providing basic information about the model such as the owner and permissioning.
 func MakeRetrieveModelRequest(model string, organizationID *string) (*ModelResponse, error) {
-	r, err := common.MakeRequest[any, ModelResponse](nil, Endpoint+"/"+model, http.MethodGet, organizationID)
+	return MakeRetrieveModelRequestWithClientContext(context.Background(), common.DefaultClient(), model, organizationID)
+}
+
+// Same as MakeRetrieveModelRequest, except the request is made with the given context.
+func MakeRetrieveModelRequestContext(ctx context.Context, model string, organizationID *string) (*ModelResponse, error) {
+	return MakeRetrieveModelRequestWithClientContext(ctx, common.DefaultClient(), model, organizationID)
+}
+
+// Same as MakeRetrieveModelRequest, except the request is sent using the given client.
+func MakeRetrieveModelRequestWithClient(client *common.Client, model string, organizationID *string) (*ModelResponse, error) {
+	return MakeRetrieveModelRequestWithClientContext(context.Background(), client, model, organizationID)
+}
+
+// Same as MakeRetrieveModelRequestWithClient, except the request is made with the given context.
+func MakeRetrieveModelRequestWithClientContext(ctx context.Context, client *common.Client, model string, organizationID *string) (*ModelResponse, error) {
+	r, err := common.MakeRequestWithClientContext[any, ModelResponse](ctx, client, nil, Endpoint+"/"+model, http.MethodGet, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,7 @@
 package moderations
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
@@ -51,10 +52,27 @@ type Response struct {
 	} `json:"results"`
 
 	Error *common.ResponseError `json:"error,omitempty"`
+
+	common.CacheMetadata
 }
 
 func MakeRequest(request *Request, organizationID *string) (*Response, error) {
-	r, err := common.MakeRequest[Request, Response](request, Endpoint, http.MethodPost, organizationID)
+	return MakeRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeRequest, except the request is made with the given context.
+func MakeRequestContext(ctx context.Context, request *Request, organizationID *string) (*Response, error) {
+	return MakeRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeRequest, except the request is sent using the given client.
+func MakeRequestWithClient(client *common.Client, request *Request, organizationID *string) (*Response, error) {
+	return MakeRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeRequestWithClient, except the request is made with the given context.
+func MakeRequestWithClientContext(ctx context.Context, client *common.Client, request *Request, organizationID *string) (*Response, error) {
+	r, err := common.MakeRequestWithClientContext[Request, Response](ctx, client, request, Endpoint, http.MethodPost, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -82,7 +100,22 @@ func NewModerationFlagError() (e *ModerationFlagError) {
 
 // Same as MakeRequest, except returns a ModerationFlagError if one or more request inputs were flagged.
 func MakeModeratedRequest(request *Request, organizationID *string) (*Response, error) {
-	r, err := MakeRequest(request, organizationID)
+	return MakeModeratedRequestWithClientContext(context.Background(), common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeModeratedRequest, except the request is made with the given context.
+func MakeModeratedRequestContext(ctx context.Context, request *Request, organizationID *string) (*Response, error) {
+	return MakeModeratedRequestWithClientContext(ctx, common.DefaultClient(), request, organizationID)
+}
+
+// Same as MakeModeratedRequest, except the request is sent using the given client.
+func MakeModeratedRequestWithClient(client *common.Client, request *Request, organizationID *string) (*Response, error) {
+	return MakeModeratedRequestWithClientContext(context.Background(), client, request, organizationID)
+}
+
+// Same as MakeModeratedRequestWithClient, except the request is made with the given context.
+func MakeModeratedRequestWithClientContext(ctx context.Context, client *common.Client, request *Request, organizationID *string) (*Response, error) {
+	r, err := MakeRequestWithClientContext(ctx, client, request, organizationID)
 	if err != nil {
 		return nil, err
 	}
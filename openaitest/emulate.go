@@ -0,0 +1,747 @@
+package openaitest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/png"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/common"
+	"github.com/Kardbord/gopenai/completions"
+	"github.com/Kardbord/gopenai/embeddings"
+	"github.com/Kardbord/gopenai/files"
+	"github.com/Kardbord/gopenai/finetuning"
+	"github.com/Kardbord/gopenai/models"
+	"github.com/Kardbord/gopenai/moderations"
+)
+
+// The text of the transcriptions and translations emulated by a Server.
+const DefaultTranscript = "This is a mock transcript."
+
+// Moderation inputs containing FlaggedContent are flagged by a Server,
+// in the violence category.
+const FlaggedContent = "openaitest:flagged"
+
+// The models listed by a Server, along with any fine-tuned models.
+var Models = []string{
+	"gpt-4o",
+	"gpt-4o-mini",
+	"gpt-3.5-turbo",
+	"gpt-3.5-turbo-instruct",
+	"text-embedding-3-small",
+	"text-embedding-3-large",
+	"text-embedding-ada-002",
+	"omni-moderation-latest",
+	"text-moderation-stable",
+	"text-moderation-latest",
+	"whisper-1",
+	"tts-1",
+	"dall-e-2",
+	"dall-e-3",
+}
+
+// The moderation categories reported by a Server.
+var moderationCategories = []string{
+	"harassment", "harassment/threatening", "hate", "hate/threatening",
+	"illicit", "illicit/violent", "self-harm", "self-harm/instructions",
+	"self-harm/intent", "sexual", "sexual/minors", "violence", "violence/graphic",
+}
+
+// Generated images are served under imagePrefix, outside the API.
+const imagePrefix = "/openaitest/images/"
+
+var mockPNG = func() []byte {
+	buf := &bytes.Buffer{}
+	png.Encode(buf, image.NewGray(image.Rect(0, 0, 1, 1)))
+	return buf.Bytes()
+}()
+
+// A mock MP3 frame header, returned as generated speech.
+var mockAudio = []byte{0xff, 0xfb, 0x90, 0x64, 0x00, 0x00, 0x00, 0x00}
+
+type storedFile struct {
+	files.UploadedFile
+	content []byte
+}
+
+func invalidRequest(param, message string) Response {
+	resp := Error(http.StatusBadRequest, "invalid_request_error", "", message)
+	resp.Body.(map[string]any)["error"] = common.ResponseError{
+		Message: message,
+		Type:    "invalid_request_error",
+		Param:   param,
+	}
+	return resp
+}
+
+func notFound(message string) Response {
+	return Error(http.StatusNotFound, "invalid_request_error", "", message)
+}
+
+func unknownURL(r *Request) Response {
+	return notFound(fmt.Sprintf("Invalid URL (%s %s)", r.Method, r.URL.Path))
+}
+
+func missing(param string) Response {
+	return invalidRequest(param, fmt.Sprintf("Missing required parameter: '%s'.", param))
+}
+
+func now() uint64 {
+	return uint64(time.Now().Unix())
+}
+
+// emulate responds to a request as the API would.
+func (s *Server) emulate(r *Request) Response {
+	parts := strings.Split(r.Endpoint, "/")
+	switch {
+	case r.Endpoint == "chat/completions" && r.Method == http.MethodPost:
+		return s.emulateChat(r)
+	case r.Endpoint == "completions" && r.Method == http.MethodPost:
+		return s.emulateCompletions(r)
+	case r.Endpoint == "embeddings" && r.Method == http.MethodPost:
+		return s.emulateEmbeddings(r)
+	case r.Endpoint == "moderations" && r.Method == http.MethodPost:
+		return s.emulateModerations(r)
+	case parts[0] == "models":
+		return s.emulateModels(r, parts[1:])
+	case parts[0] == "files":
+		return s.emulateFiles(r, parts[1:])
+	case len(parts) >= 2 && parts[0] == "fine_tuning" && parts[1] == "jobs":
+		return s.emulateFineTuning(r, parts[2:])
+	case parts[0] == "images" && len(parts) == 2 && r.Method == http.MethodPost:
+		return s.emulateImages(r, parts[1])
+	case parts[0] == "audio" && len(parts) == 2 && r.Method == http.MethodPost:
+		return s.emulateAudio(r, parts[1])
+	}
+	return unknownURL(r)
+}
+
+func (s *Server) emulateChat(r *Request) Response {
+	request := chat.Request{}
+	if err := r.Decode(&request); err != nil {
+		return invalidRequest("", "We could not parse the JSON body of your request.")
+	}
+	if len(request.Model) == 0 {
+		return missing("model")
+	}
+	if len(request.Messages) == 0 {
+		return invalidRequest("messages", "[] is too short - 'messages'")
+	}
+
+	n := int64(1)
+	if request.N != nil && *request.N > 1 {
+		n = *request.N
+	}
+	content := DefaultContent
+	if format := request.ResponseFormat; format != nil {
+		switch format.Type {
+		case chat.ResponseFormatJSONObject:
+			content = "{}"
+		case chat.ResponseFormatJSONSchema:
+			if format.JSONSchema == nil {
+				return missing("response_format.json_schema")
+			}
+			example, _ := json.Marshal(exampleFor(format.JSONSchema.Schema))
+			content = string(example)
+		}
+	}
+
+	usage := common.ResponseUsage{CompletionTokens: uint64(common.EstimateTokens(content) * n)}
+	for i := range request.Messages {
+		usage.PromptTokens += uint64(common.EstimateTokens(request.Messages[i].Text()))
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	s.mu.Lock()
+	id := s.nextID("chatcmpl")
+	s.mu.Unlock()
+
+	if request.Stream {
+		var streamUsage *common.ResponseUsage
+		if request.StreamOptions != nil && request.StreamOptions.IncludeUsage {
+			streamUsage = &usage
+		}
+		return Stream(chatChunks(request.Model, id, n, content, streamUsage)...)
+	}
+
+	choices := make([]chat.Choice, n)
+	for i := range choices {
+		choices[i] = chat.Choice{
+			Message:      chat.Chat{Role: chat.AssistantRole, Content: content},
+			FinishReason: "stop",
+		}
+	}
+	return JSON(chatCompletion(request.Model, id, choices, usage))
+}
+
+func (s *Server) emulateCompletions(r *Request) Response {
+	request := completions.Request{}
+	if err := r.Decode(&request); err != nil {
+		return invalidRequest("", "We could not parse the JSON body of your request.")
+	}
+	if len(request.Model) == 0 {
+		return missing("model")
+	}
+
+	prompts := len(request.Prompt)
+	if prompts == 0 {
+		prompts = 1
+	}
+	n := 1
+	if request.N != nil && *request.N > 1 {
+		n = int(*request.N)
+	}
+	usage := common.ResponseUsage{CompletionTokens: uint64(common.EstimateTokens(DefaultContent) * int64(n*prompts))}
+	for _, p := range request.Prompt {
+		usage.PromptTokens += uint64(common.EstimateTokens(p))
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	s.mu.Lock()
+	id := s.nextID("cmpl")
+	s.mu.Unlock()
+	created := now()
+
+	if request.Stream {
+		chunks := []any{}
+		for _, word := range strings.SplitAfter(DefaultContent, " ") {
+			choices := []map[string]any{}
+			for i := 0; i < n*prompts; i++ {
+				choices = append(choices, map[string]any{"text": word, "index": i, "finish_reason": nil})
+			}
+			chunks = append(chunks, map[string]any{"id": id, "object": "text_completion", "created": created, "model": request.Model, "choices": choices})
+		}
+		if request.StreamOptions != nil && request.StreamOptions.IncludeUsage {
+			chunks = append(chunks, map[string]any{"id": id, "object": "text_completion", "created": created, "model": request.Model, "choices": []any{}, "usage": usage})
+		}
+		return Stream(chunks...)
+	}
+
+	choices := make([]completions.Choice, n*prompts)
+	for i := range choices {
+		choices[i] = completions.Choice{Text: DefaultContent, Index: uint64(i), FinishReason: "stop"}
+	}
+	return JSON(&completions.Response{
+		ID:      id,
+		Object:  "text_completion",
+		Created: created,
+		Model:   request.Model,
+		Choices: choices,
+		Usage:   usage,
+	})
+}
+
+func (s *Server) emulateEmbeddings(r *Request) Response {
+	request := embeddings.Request{}
+	if err := r.Decode(&request); err != nil {
+		return invalidRequest("input", "'input' must be a string or an array of strings.")
+	}
+	if len(request.Model) == 0 {
+		return missing("model")
+	}
+	if len(request.Input) == 0 {
+		return missing("input")
+	}
+
+	dimensions := 1536
+	if strings.HasSuffix(request.Model, "-large") {
+		dimensions = 3072
+	}
+	data := []map[string]any{}
+	usage := common.ResponseUsage{}
+	for i, input := range request.Input {
+		vector := Embedding(input, dimensions)
+		var embedding any = vector
+		if request.EncodingFormat == "base64" {
+			buf := make([]byte, 4*len(vector))
+			for j, v := range vector {
+				binary.LittleEndian.PutUint32(buf[4*j:], math.Float32bits(float32(v)))
+			}
+			embedding = base64.StdEncoding.EncodeToString(buf)
+		}
+		data = append(data, map[string]any{"object": "embedding", "index": i, "embedding": embedding})
+		usage.PromptTokens += uint64(common.EstimateTokens(input))
+	}
+	usage.TotalTokens = usage.PromptTokens
+	return JSON(map[string]any{"object": "list", "data": data, "model": request.Model, "usage": usage})
+}
+
+// Embedding returns the deterministic unit vector with the given number
+// of dimensions which a Server returns as the embedding of input.
+func Embedding(input string, dimensions int) []float64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(input))
+	state := hash.Sum64()
+
+	vector := make([]float64, dimensions)
+	var norm float64
+	for i := range vector {
+		// xorshift64* produces a deterministic sequence from the hash.
+		state ^= state >> 12
+		state ^= state << 25
+		state ^= state >> 27
+		vector[i] = float64(state*2685821657736338717)/math.MaxUint64*2 - 1
+		norm += vector[i] * vector[i]
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] /= norm
+	}
+	return vector
+}
+
+func (s *Server) emulateModerations(r *Request) Response {
+	request := moderations.Request{}
+	if err := r.Decode(&request); err != nil {
+		return invalidRequest("input", "'input' must be a string or an array of strings.")
+	}
+	if len(request.Input) == 0 {
+		return missing("input")
+	}
+	model := request.Model
+	if len(model) == 0 {
+		model = "omni-moderation-latest"
+	}
+
+	results := []map[string]any{}
+	for _, input := range request.Input {
+		flagged := strings.Contains(input, FlaggedContent)
+		categories := map[string]bool{}
+		scores := map[string]float64{}
+		for _, category := range moderationCategories {
+			categories[category] = false
+			scores[category] = 0.0001
+		}
+		if flagged {
+			categories["violence"] = true
+			scores["violence"] = 0.99
+		}
+		results = append(results, map[string]any{"flagged": flagged, "categories": categories, "category_scores": scores})
+	}
+	s.mu.Lock()
+	id := s.nextID("modr")
+	s.mu.Unlock()
+	return JSON(map[string]any{"id": id, "model": model, "results": results})
+}
+
+func model(id, ownedBy string) models.ModelResponse {
+	return models.ModelResponse{ID: id, Object: "model", Created: 1700000000, OwnedBy: ownedBy, Root: id}
+}
+
+func (s *Server) emulateModels(r *Request, path []string) Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fineTuned := map[string]bool{}
+	for _, id := range s.jobOrder {
+		if job := s.jobs[id]; len(job.FineTunedModel) != 0 {
+			fineTuned[job.FineTunedModel] = true
+		}
+	}
+
+	switch {
+	case len(path) == 0 && r.Method == http.MethodGet:
+		list := models.ListModelsResponse{Object: "list"}
+		for _, id := range Models {
+			list.Data = append(list.Data, model(id, "system"))
+		}
+		for _, id := range s.jobOrder {
+			if fineTuned[s.jobs[id].FineTunedModel] {
+				list.Data = append(list.Data, model(s.jobs[id].FineTunedModel, "user"))
+			}
+		}
+		return JSON(list)
+	case len(path) == 1 && r.Method == http.MethodGet:
+		for _, id := range Models {
+			if id == path[0] {
+				return JSON(model(id, "system"))
+			}
+		}
+		if fineTuned[path[0]] {
+			return JSON(model(path[0], "user"))
+		}
+	case len(path) == 1 && r.Method == http.MethodDelete:
+		if fineTuned[path[0]] {
+			for _, job := range s.jobs {
+				if job.FineTunedModel == path[0] {
+					job.FineTunedModel = ""
+				}
+			}
+			return JSON(map[string]any{"id": path[0], "object": "model", "deleted": true})
+		}
+	default:
+		return unknownURL(r)
+	}
+	return notFound(fmt.Sprintf("The model '%s' does not exist", path[0]))
+}
+
+func (s *Server) emulateFiles(r *Request, path []string) Response {
+	switch {
+	case len(path) == 0 && r.Method == http.MethodPost:
+		purpose := r.FormValue("purpose")
+		if len(purpose) == 0 {
+			return missing("purpose")
+		}
+		filename, content, err := r.FormFile("file")
+		if err != nil {
+			return missing("file")
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		file := &storedFile{
+			UploadedFile: files.UploadedFile{
+				ID:        s.nextID("file"),
+				Object:    "file",
+				Bytes:     uint64(len(content)),
+				CreatedAt: now(),
+				Filename:  filename,
+				Purpose:   purpose,
+			},
+			content: content,
+		}
+		s.files[file.ID] = file
+		s.fileOrder = append(s.fileOrder, file.ID)
+		return JSON(file.UploadedFile)
+	case len(path) == 0 && r.Method == http.MethodGet:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		list := files.ListResponse{Object: "list", Data: []files.UploadedFile{}}
+		purpose := r.URL.Query().Get("purpose")
+		for _, id := range s.fileOrder {
+			if file := s.files[id]; len(purpose) == 0 || file.Purpose == purpose {
+				list.Data = append(list.Data, file.UploadedFile)
+			}
+		}
+		return JSON(list)
+	case len(path) > 2 || (len(path) == 2 && path[1] != "content"):
+		return unknownURL(r)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[path[0]]
+	if !ok {
+		return notFound(fmt.Sprintf("No such File object: %s", path[0]))
+	}
+	switch {
+	case len(path) == 2 && r.Method == http.MethodGet:
+		return Response{Header: http.Header{"Content-Type": {"application/octet-stream"}}, Body: file.content}
+	case len(path) == 1 && r.Method == http.MethodGet:
+		return JSON(file.UploadedFile)
+	case len(path) == 1 && r.Method == http.MethodDelete:
+		delete(s.files, file.ID)
+		for i, id := range s.fileOrder {
+			if id == file.ID {
+				s.fileOrder = append(s.fileOrder[:i], s.fileOrder[i+1:]...)
+				break
+			}
+		}
+		return JSON(files.DeleteResponse{ID: file.ID, Object: "file", Deleted: true})
+	}
+	return unknownURL(r)
+}
+
+// Fine-tuning jobs advance from queued to running to succeeded each time
+// they are retrieved, so that clients polling a job see it complete.
+var jobTransitions = map[string]string{
+	"validating_files": "queued",
+	"queued":           "running",
+	"running":          "succeeded",
+}
+
+func (s *Server) emulateFineTuning(r *Request, path []string) Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case len(path) == 0 && r.Method == http.MethodPost:
+		request := finetuning.CreationRequest{}
+		if err := r.Decode(&request); err != nil {
+			return invalidRequest("", "We could not parse the JSON body of your request.")
+		}
+		if len(request.Model) == 0 {
+			return missing("model")
+		}
+		if len(request.TrainingFile) == 0 {
+			return missing("training_file")
+		}
+		if _, ok := s.files[request.TrainingFile]; !ok {
+			return invalidRequest("training_file", fmt.Sprintf("invalid training_file: %s", request.TrainingFile))
+		}
+		job := &finetuning.FineTune{
+			ID:             s.nextID("ftjob"),
+			Object:         "fine_tuning.job",
+			CreatedAt:      now(),
+			Hyperparams:    request.Hyperparams,
+			Model:          request.Model,
+			OrganizationID: "org-openaitest",
+			ResultFiles:    []string{},
+			Status:         "validating_files",
+			TrainingFile:   request.TrainingFile,
+		}
+		if request.ValidationFile != nil {
+			job.ValidationFile = *request.ValidationFile
+		}
+		s.jobs[job.ID] = job
+		s.jobOrder = append(s.jobOrder, job.ID)
+		return JSON(job)
+	case len(path) == 0 && r.Method == http.MethodGet:
+		query := r.URL.Query()
+		jobs := []*finetuning.FineTune{}
+		// Jobs are listed from the most recently created.
+		for i := len(s.jobOrder) - 1; i >= 0; i-- {
+			jobs = append(jobs, s.jobs[s.jobOrder[i]])
+		}
+		return JSON(paginate(jobs, query.Get("after"), query.Get("limit"), func(job *finetuning.FineTune) string { return job.ID }))
+	}
+
+	job, ok := s.jobs[path[0]]
+	if !ok {
+		return notFound(fmt.Sprintf("Could not find fine tune: %s", path[0]))
+	}
+	switch {
+	case len(path) == 1 && r.Method == http.MethodGet:
+		if next, ok := jobTransitions[job.Status]; ok {
+			job.Status = next
+			if next == "succeeded" {
+				job.FinishedAt = now()
+				job.TrainedTokens = uint64(len(s.files[job.TrainingFile].content) / 4)
+				job.FineTunedModel = fmt.Sprintf("ft:%s:openaitest::%s", job.Model, strings.TrimPrefix(job.ID, "ftjob-"))
+			}
+		}
+		return JSON(job)
+	case len(path) == 2 && path[1] == "cancel" && r.Method == http.MethodPost:
+		if _, ok := jobTransitions[job.Status]; !ok {
+			return invalidRequest("", fmt.Sprintf("Job has already completed: %s", job.ID))
+		}
+		job.Status = "cancelled"
+		return JSON(job)
+	case len(path) == 2 && path[1] == "events" && r.Method == http.MethodGet:
+		events := []finetuning.FineTuneEvent{}
+		for i, status := range []string{"validating_files", "queued", "running", "succeeded", "cancelled"} {
+			events = append([]finetuning.FineTuneEvent{{
+				ID:        fmt.Sprintf("ftevent-%s-%d", strings.TrimPrefix(job.ID, "ftjob-"), i),
+				CreatedAt: job.CreatedAt,
+				Level:     "info",
+				Message:   "Job status: " + status,
+				Object:    "fine_tuning.job.event",
+				Type:      "message",
+			}}, events...)
+			if status == job.Status {
+				break
+			}
+		}
+		query := r.URL.Query()
+		return JSON(paginate(events, query.Get("after"), query.Get("limit"), func(e finetuning.FineTuneEvent) string { return e.ID }))
+	}
+	return unknownURL(r)
+}
+
+// paginate returns the page of items after the one with the given ID, as a
+// list response.
+func paginate[T any](items []T, after, limit string, id func(T) string) map[string]any {
+	if len(after) != 0 {
+		for i, item := range items {
+			if id(item) == after {
+				items = items[i+1:]
+				break
+			}
+		}
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		n = 20
+	}
+	hasMore := len(items) > n
+	if hasMore {
+		items = items[:n]
+	}
+	return map[string]any{"object": "list", "data": items, "has_more": hasMore}
+}
+
+func (s *Server) emulateImages(r *Request, operation string) Response {
+	var prompt, model, responseFormat, n string
+	switch operation {
+	case "generations":
+		var request struct {
+			Prompt         string  `json:"prompt"`
+			Model          string  `json:"model"`
+			N              *uint64 `json:"n"`
+			ResponseFormat string  `json:"response_format"`
+		}
+		if err := r.Decode(&request); err != nil {
+			return invalidRequest("", "We could not parse the JSON body of your request.")
+		}
+		prompt, model, responseFormat = request.Prompt, request.Model, request.ResponseFormat
+		if request.N != nil {
+			n = strconv.FormatUint(*request.N, 10)
+		}
+		if len(prompt) == 0 {
+			return missing("prompt")
+		}
+	case "edits", "variations":
+		if _, _, err := r.FormFile("image"); err != nil {
+			return missing("image")
+		}
+		prompt, model, responseFormat, n = r.FormValue("prompt"), r.FormValue("model"), r.FormValue("response_format"), r.FormValue("n")
+		if operation == "edits" && len(prompt) == 0 {
+			return missing("prompt")
+		}
+	default:
+		return unknownURL(r)
+	}
+
+	count := 1
+	if len(n) != 0 {
+		var err error
+		if count, err = strconv.Atoi(n); err != nil || count < 1 || count > 10 {
+			return invalidRequest("n", fmt.Sprintf("%s is not a valid value for 'n'.", n))
+		}
+	}
+	if model == "dall-e-3" && count != 1 {
+		return invalidRequest("n", "You must provide n=1 for this model.")
+	}
+
+	data := []map[string]any{}
+	s.mu.Lock()
+	for i := 0; i < count; i++ {
+		image := map[string]any{}
+		if responseFormat == "b64_json" {
+			image["b64_json"] = base64.StdEncoding.EncodeToString(mockPNG)
+		} else {
+			image["url"] = s.URL + imagePrefix + s.nextID("img") + ".png"
+		}
+		if model == "dall-e-3" {
+			image["revised_prompt"] = prompt
+		}
+		data = append(data, image)
+	}
+	s.mu.Unlock()
+	return JSON(map[string]any{"created": now(), "data": data})
+}
+
+func (s *Server) emulateAudio(r *Request, operation string) Response {
+	switch operation {
+	case "transcriptions", "translations":
+		if _, _, err := r.FormFile("file"); err != nil {
+			return missing("file")
+		}
+		if len(r.FormValue("model")) == 0 {
+			return missing("model")
+		}
+		switch r.FormValue("response_format") {
+		case "text", "srt", "vtt":
+			return Response{Body: DefaultTranscript}
+		}
+		return JSON(map[string]any{"text": DefaultTranscript})
+	case "speech":
+		var request struct {
+			Model          string `json:"model"`
+			Input          string `json:"input"`
+			Voice          string `json:"voice"`
+			ResponseFormat string `json:"response_format"`
+		}
+		if err := r.Decode(&request); err != nil {
+			return invalidRequest("", "We could not parse the JSON body of your request.")
+		}
+		for _, field := range []struct{ name, value string }{{"model", request.Model}, {"input", request.Input}, {"voice", request.Voice}} {
+			if len(field.value) == 0 {
+				return missing(field.name)
+			}
+		}
+		contentTypes := map[string]string{"opus": "audio/ogg", "aac": "audio/aac", "flac": "audio/flac", "wav": "audio/wav", "pcm": "audio/pcm"}
+		contentType, ok := contentTypes[request.ResponseFormat]
+		if !ok {
+			contentType = "audio/mpeg"
+		}
+		return Response{Header: http.Header{"Content-Type": {contentType}}, Body: mockAudio}
+	}
+	return unknownURL(r)
+}
+
+// exampleFor returns a value which is valid against the given JSON schema,
+// so that structured outputs can be decoded from an emulated response.
+func exampleFor(schema any) any {
+	var root map[string]any
+	data, err := json.Marshal(schema)
+	if err != nil || json.Unmarshal(data, &root) != nil {
+		return map[string]any{}
+	}
+	return example(root, root, 0)
+}
+
+func example(schema, root map[string]any, depth int) any {
+	if depth > 16 || schema == nil {
+		return nil
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		if ref == "#" {
+			return example(root, root, depth+1)
+		}
+		defs, _ := root["$defs"].(map[string]any)
+		def, _ := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		return example(def, root, depth+1)
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) != 0 {
+		return enum[0]
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		for _, option := range anyOf {
+			if option, ok := option.(map[string]any); ok && option["type"] != "null" {
+				return example(option, root, depth+1)
+			}
+		}
+		return nil
+	}
+
+	var schemaType string
+	switch t := schema["type"].(type) {
+	case string:
+		schemaType = t
+	case []any:
+		for _, t := range t {
+			if t, ok := t.(string); ok && t != "null" {
+				schemaType = t
+				break
+			}
+		}
+	}
+	switch schemaType {
+	case "object":
+		object := map[string]any{}
+		properties, _ := schema["properties"].(map[string]any)
+		names := make([]string, 0, len(properties))
+		for name := range properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, _ := properties[name].(map[string]any)
+			object[name] = example(property, root, depth+1)
+		}
+		return object
+	case "array":
+		return []any{}
+	case "string":
+		if schema["format"] == "date-time" {
+			return time.Unix(0, 0).UTC().Format(time.RFC3339)
+		}
+		return ""
+	case "number", "integer":
+		return 0
+	case "boolean":
+		return false
+	}
+	return nil
+}
@@ -0,0 +1,390 @@
+package openaitest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	auth "github.com/Kardbord/gopenai/authentication"
+	"github.com/Kardbord/gopenai/common"
+)
+
+// The environment variable which, when set along with OPENAI_API_KEY,
+// makes MainWithCassette record a new cassette from the live API.
+const RecordEnvVar = "OPENAITEST_RECORD"
+
+// ErrNoInteraction is returned by a replaying Recorder
+// for requests which were not recorded in its cassette.
+var ErrNoInteraction = errors.New("openaitest: no recorded interaction matches the request")
+
+// The headers which are removed from recorded interactions,
+// as they may hold credentials or identify an account.
+var ScrubbedHeaders = []string{
+	auth.AuthHeaderKey,
+	auth.OrgHeaderKey,
+	auth.ProjectHeaderKey,
+	"Api-Key",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// The query parameters whose values are redacted from recorded interactions,
+// as they may hold credentials. They are matched regardless of case.
+var ScrubbedQueryParams = []string{
+	"api-key",
+	"api_key",
+	"key",
+	"access_token",
+	"token",
+	"client_secret",
+	"secret",
+	"password",
+	"sig",
+	"signature",
+}
+
+// The boundary which replaces those of recorded multipart bodies,
+// which are otherwise random.
+const cassetteBoundary = "openaitest-boundary"
+
+// A Mode is the mode of a Recorder.
+type Mode int
+
+const (
+	// Replay responds to requests with the responses recorded in a cassette.
+	Replay Mode = iota
+	// Record sends requests to the API, recording each response.
+	Record
+)
+
+// A Cassette holds the interactions recorded by a Recorder.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// An Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+
+	replayed bool
+}
+
+// A RecordedRequest is a request recorded in a Cassette.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// A RecordedResponse is a response recorded in a Cassette.
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// A Body is a recorded request or response body. Bodies which are not
+// valid UTF-8 are encoded as base64 in cassettes, and others as is.
+type Body []byte
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = Body(text)
+		return nil
+	}
+	var encoded struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded.Base64)
+	*b = decoded
+	return err
+}
+
+// A Recorder is an http.RoundTripper which records requests and their
+// responses into a cassette file, to later replay them without the API.
+//
+// A replayed request matches a recorded one with the same method, URL and
+// body. JSON bodies are compared regardless of formatting and key order,
+// and multipart bodies regardless of their boundary. Each recorded
+// interaction is replayed once, in order, so that repeated requests may
+// receive different responses.
+type Recorder struct {
+	// The transport used to send requests while recording.
+	// Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	mu       sync.Mutex
+	path     string
+	mode     Mode
+	cassette Cassette
+}
+
+// NewRecorder returns a Recorder in the given mode. When replaying, the
+// cassette at path is loaded. When recording, it is written by Save.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode}
+	if mode == Record {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("openaitest: decoding cassette %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Mode returns the mode of the recorder.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns a client which sends requests through the recorder,
+// configured with the given options.
+func (r *Recorder) Client(opts ...common.ClientOption) *common.Client {
+	return common.NewClient(append([]common.ClientOption{
+		common.WithHTTPClient(&http.Client{Transport: r}),
+	}, opts...)...)
+}
+
+// Save writes the recorded interactions to the cassette file.
+// It does nothing when replaying.
+func (r *Recorder) Save() error {
+	if r.mode != Record {
+		return nil
+	}
+	r.mu.Lock()
+	data, err := json.MarshalIndent(&r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    scrubURL(req.URL),
+		Header: scrub(req.Header),
+		Body:   normalizeBody(req.Header.Get("Content-Type"), body),
+	}
+
+	if r.mode == Record {
+		return r.record(req, body, recorded)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, interaction := range r.cassette.Interactions {
+		if !interaction.replayed && matches(&interaction.Request, &recorded) {
+			interaction.replayed = true
+			return interaction.Response.response(req), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, recorded.Method, recorded.URL)
+}
+
+func (r *Recorder) record(req *http.Request, body []byte, recorded RecordedRequest) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	sent := req.Clone(req.Context())
+	sent.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := transport.RoundTrip(sent)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := &Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: scrub(resp.Header),
+			Body:   respBody,
+		},
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+func (r *RecordedResponse) response(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+func scrub(header http.Header) http.Header {
+	header = header.Clone()
+	for _, key := range ScrubbedHeaders {
+		header.Del(key)
+	}
+	return header
+}
+
+// scrubURL removes the user information of u, and redacts the values of
+// its ScrubbedQueryParams. Both recorded and replayed requests are scrubbed,
+// so that they still match.
+func scrubURL(u *url.URL) string {
+	scrubbed := *u
+	scrubbed.User = nil
+	query := scrubbed.Query()
+	for param := range query {
+		for _, key := range ScrubbedQueryParams {
+			if strings.EqualFold(param, key) {
+				query[param] = []string{common.RedactedValue}
+			}
+		}
+	}
+	scrubbed.RawQuery = query.Encode()
+	return scrubbed.String()
+}
+
+// normalizeBody replaces the random boundary of a multipart body,
+// so that it matches the same body when replayed.
+func normalizeBody(contentType string, body []byte) []byte {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || len(params["boundary"]) == 0 {
+		return body
+	}
+	return bytes.ReplaceAll(body, []byte(params["boundary"]), []byte(cassetteBoundary))
+}
+
+func matches(recorded, req *RecordedRequest) bool {
+	return recorded.Method == req.Method &&
+		normalizeURL(recorded.URL) == normalizeURL(req.URL) &&
+		normalizeJSON(recorded.Body) == normalizeJSON(req.Body)
+}
+
+// normalizeURL sorts the query parameters of rawURL.
+func normalizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.RawQuery = u.Query().Encode()
+	return u.String()
+}
+
+// normalizeJSON re-encodes a JSON body with sorted keys and no
+// whitespace. Other bodies are returned as is.
+func normalizeJSON(body []byte) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+	normalized, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(normalized)
+}
+
+// MainWithCassette runs the tests of m like Main, except that when the
+// OPENAI_API_KEY environment variable is not set, requests are replayed
+// from the cassette at path. When both OPENAI_API_KEY and OPENAITEST_RECORD
+// are set, requests are sent to the live API and recorded into the cassette
+// at path. If neither is set and the cassette does not exist, the tests are
+// not run and MainWithCassette fails, rather than testing against the mock.
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(openaitest.MainWithCassette(m, "testdata/cassette.json"))
+//	}
+func MainWithCassette(m *testing.M, path string) int {
+	key := os.Getenv(auth.APIKeyEnvVar)
+	var mode Mode
+	switch {
+	case len(key) != 0 && len(os.Getenv(RecordEnvVar)) != 0:
+		mode = Record
+	case len(key) == 0:
+		if _, err := os.Stat(path); err != nil {
+			fmt.Fprintf(os.Stderr, "openaitest: cassette %s is missing, record it by running the tests with %s and %s set: %v\n",
+				path, auth.APIKeyEnvVar, RecordEnvVar, err)
+			return 1
+		}
+		mode = Replay
+	default:
+		return Main(m)
+	}
+
+	recorder, err := NewRecorder(path, mode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if mode == Record {
+		auth.SetAPIKey(key)
+	}
+	common.SetDefaultClient(recorder.Client())
+	defer common.SetDefaultClient(nil)
+
+	code := m.Run()
+	if err := recorder.Save(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return code
+}
@@ -0,0 +1,189 @@
+package openaitest_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/common"
+	"github.com/Kardbord/gopenai/files"
+	"github.com/Kardbord/gopenai/openaitest"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "cassette.json")
+	server := openaitest.NewServer()
+	defer server.Close()
+
+	// The requests are sent to the mock server in place of the live API.
+	send := func(recorder *openaitest.Recorder) (string, []string) {
+		client := recorder.Client(common.WithAPIKey(openaitest.APIKey), common.WithBaseURL(server.BaseURL()))
+		resp, err := chat.MakeRequestWithClient(client, request, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for i := 0; i < 2; i++ {
+			file, err := files.MakeUploadRequestWithClient(client, &files.UploadRequest{
+				Purpose:  "fine-tune",
+				Filename: "data.jsonl",
+				Filepath: "../files/test_files/testdata.jsonl",
+			}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, file.ID)
+		}
+		return resp.ID, ids
+	}
+
+	recorder, err := openaitest.NewRecorder(path, openaitest.Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.Transport = server.Server.Client().Transport
+	recordedID, recordedFiles := send(recorder)
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	cassette, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(cassette), openaitest.APIKey) {
+		t.Fatal("expected the API key to be scrubbed from the cassette")
+	}
+
+	server.Reset()
+	recorder, err = openaitest.NewRecorder(path, openaitest.Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayedID, replayedFiles := send(recorder)
+	if replayedID != recordedID || strings.Join(replayedFiles, ",") != strings.Join(recordedFiles, ",") {
+		t.Fatalf("expected the recorded responses in order, got %s %v", replayedID, replayedFiles)
+	}
+	if n := len(server.Requests()); n != 0 {
+		t.Fatalf("expected no requests to be sent while replaying, got %d", n)
+	}
+
+	_, err = chat.MakeRequestWithClient(recorder.Client(common.WithBaseURL(server.BaseURL())), request, nil)
+	if !errors.Is(err, openaitest.ErrNoInteraction) {
+		t.Fatalf("expected ErrNoInteraction once every interaction is replayed, got %v", err)
+	}
+}
+
+func TestRecorderJSONMatching(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette := `{"interactions": [{
+		"request": {"method": "POST", "url": "https://api.openai.com/v1/chat/completions",
+			"body": "{\"model\": \"gpt-4o-mini\", \"messages\": [{\"content\": \"Hello\", \"role\": \"user\"}]}"},
+		"response": {"status": 200, "header": {"Content-Type": ["application/json"]},
+			"body": "{\"id\": \"chatcmpl-recorded\", \"choices\": [{\"message\": {\"role\": \"assistant\", \"content\": \"Hi\"}}]}"}
+	}]}`
+	if err := os.WriteFile(path, []byte(cassette), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	recorder, err := openaitest.NewRecorder(path, openaitest.Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := chat.MakeRequestWithClient(recorder.Client(), request, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != "chatcmpl-recorded" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestRecorderScrubsURL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	server := openaitest.NewServer()
+	defer server.Close()
+	rawURL := strings.Replace(server.BaseURL(), "://", "://user:hunter2@", 1) + "/models?api-version=1&API-KEY=sk-secret"
+
+	send := func(recorder *openaitest.Recorder) {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := recorder.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	recorder, err := openaitest.NewRecorder(path, openaitest.Record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.Transport = server.Server.Client().Transport
+	send(recorder)
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	cassette, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(cassette), "hunter2") || strings.Contains(string(cassette), "sk-secret") {
+		t.Fatalf("expected the URL to be scrubbed from the cassette: %s", cassette)
+	}
+	if !strings.Contains(string(cassette), "api-version=1") {
+		t.Fatalf("expected other query parameters to be kept: %s", cassette)
+	}
+
+	recorder, err = openaitest.NewRecorder(path, openaitest.Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	send(recorder)
+}
+
+func TestCommittedCassettes(t *testing.T) {
+	paths, err := filepath.Glob("../*/testdata/cassette.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("expected committed cassettes")
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cassette := openaitest.Cassette{}
+		if err := json.Unmarshal(data, &cassette); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		for _, interaction := range cassette.Interactions {
+			for _, header := range []http.Header{interaction.Request.Header, interaction.Response.Header} {
+				for _, key := range openaitest.ScrubbedHeaders {
+					if len(header.Values(key)) != 0 {
+						t.Errorf("%s: expected the %s header to be scrubbed", path, key)
+					}
+				}
+			}
+			u, err := url.Parse(interaction.Request.URL)
+			if err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+			if u.User != nil {
+				t.Errorf("%s: expected the user information of %s to be scrubbed", path, u.Redacted())
+			}
+		}
+		if strings.Contains(string(data), "sk-") {
+			t.Errorf("%s: expected no API keys", path)
+		}
+	}
+}
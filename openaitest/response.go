@@ -0,0 +1,209 @@
+package openaitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/common"
+)
+
+// The content of the chat and completions responses emulated by a Server.
+const DefaultContent = "This is a mock response."
+
+// A Response is a scripted response to a request.
+type Response struct {
+	// Defaults to 200.
+	Status int
+
+	Header http.Header
+
+	// The body of the response. A string or []byte is sent as is, and
+	// anything else is encoded as JSON.
+	Body any
+
+	// If set, each chunk is sent as a server-sent event, encoded like
+	// Body, followed by the [DONE] message. Body is then ignored.
+	Stream []any
+
+	// The time to wait before responding.
+	Delay time.Duration
+}
+
+// JSON returns a successful response with body encoded as JSON.
+func JSON(body any) Response {
+	return Response{Body: body}
+}
+
+// Error returns an error response, as sent by the API.
+func Error(status int, errType, code, message string) Response {
+	return Response{
+		Status: status,
+		Body: map[string]any{"error": common.ResponseError{
+			Message: message,
+			Type:    errType,
+			Code:    code,
+		}},
+	}
+}
+
+// RateLimited returns a 429 response, as sent by the API when a rate
+// limit is exceeded, which asks the client to retry after retryAfter.
+func RateLimited(retryAfter time.Duration) Response {
+	resp := Error(http.StatusTooManyRequests, "requests", "rate_limit_exceeded", "Rate limit reached. Please try again later.")
+	resp.Header = http.Header{}
+	resp.Header.Set("retry-after-ms", strconv.FormatInt(retryAfter.Milliseconds(), 10))
+	resp.Header.Set("retry-after", strconv.FormatInt(int64(retryAfter.Round(time.Second)/time.Second), 10))
+	resp.Header.Set("x-ratelimit-remaining-requests", "0")
+	resp.Header.Set("x-ratelimit-reset-requests", retryAfter.String())
+	return resp
+}
+
+// Stream returns a successful streaming response, which sends each of
+// chunks as a server-sent event.
+func Stream(chunks ...any) Response {
+	if chunks == nil {
+		chunks = []any{}
+	}
+	return Response{Stream: chunks}
+}
+
+// ChatResponse returns a chat completion whose only choice is an
+// assistant message with the given content.
+func ChatResponse(content string) Response {
+	return JSON(chatCompletion("gpt-4o-mini", "chatcmpl-openaitest", []chat.Choice{{
+		Message:      chat.Chat{Role: chat.AssistantRole, Content: content},
+		FinishReason: "stop",
+	}}, common.ResponseUsage{}))
+}
+
+// ChatToolCallResponse returns a chat completion whose only choice is an
+// assistant message which calls the given tools. Calls without an ID
+// or type are given one.
+func ChatToolCallResponse(calls ...chat.ToolCall) Response {
+	for i := range calls {
+		if len(calls[i].ID) == 0 {
+			calls[i].ID = fmt.Sprintf("call_openaitest%d", i)
+		}
+		if len(calls[i].Type) == 0 {
+			calls[i].Type = "function"
+		}
+	}
+	return JSON(chatCompletion("gpt-4o-mini", "chatcmpl-openaitest", []chat.Choice{{
+		Message:      chat.Chat{Role: chat.AssistantRole, ToolCalls: calls},
+		FinishReason: "tool_calls",
+	}}, common.ResponseUsage{}))
+}
+
+// ChatStream returns a streaming chat completion which sends the given
+// content word by word.
+func ChatStream(content string) Response {
+	return Stream(chatChunks("gpt-4o-mini", "chatcmpl-openaitest", 1, content, nil)...)
+}
+
+func chatCompletion(model, id string, choices []chat.Choice, usage common.ResponseUsage) *chat.Response {
+	for i := range choices {
+		choices[i].Index = int64(i)
+	}
+	return &chat.Response{
+		ID:      id,
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: choices,
+		Usage:   usage,
+	}
+}
+
+// chatChunks splits content into the chunks of a streamed chat completion,
+// for each of n choices. If usage is set, it is sent in a final chunk.
+func chatChunks(model, id string, n int64, content string, usage *common.ResponseUsage) []any {
+	chunk := func(choices []chat.StreamChoice) *chat.StreamChunk {
+		return &chat.StreamChunk{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   model,
+			Choices: choices,
+		}
+	}
+	forEach := func(choice chat.StreamChoice) []chat.StreamChoice {
+		choices := make([]chat.StreamChoice, n)
+		for i := range choices {
+			choices[i] = choice
+			choices[i].Index = int64(i)
+		}
+		return choices
+	}
+
+	chunks := []any{chunk(forEach(chat.StreamChoice{Delta: chat.Delta{Role: chat.AssistantRole}}))}
+	for _, word := range strings.SplitAfter(content, " ") {
+		if len(word) != 0 {
+			chunks = append(chunks, chunk(forEach(chat.StreamChoice{Delta: chat.Delta{Content: word}})))
+		}
+	}
+	chunks = append(chunks, chunk(forEach(chat.StreamChoice{FinishReason: "stop"})))
+	if usage != nil {
+		final := chunk([]chat.StreamChoice{})
+		final.Usage = usage
+		chunks = append(chunks, final)
+	}
+	return chunks
+}
+
+func encode(v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("openaitest: encoding response: %v", err))
+	}
+	return data
+}
+
+func (r Response) write(w http.ResponseWriter, requestID string) {
+	for key, values := range r.Header {
+		w.Header()[key] = values
+	}
+	w.Header().Set(common.RequestIDHeaderKey, requestID)
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if r.Stream != nil {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(status)
+		flusher, _ := w.(http.Flusher)
+		for _, chunk := range r.Stream {
+			fmt.Fprintf(w, "data: %s\n\n", encode(chunk))
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		fmt.Fprintf(w, "data: %s\n\n", common.StreamDoneData)
+		return
+	}
+
+	body := encode(r.Body)
+	if len(w.Header().Get("Content-Type")) == 0 {
+		switch r.Body.(type) {
+		case []byte, string:
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		default:
+			w.Header().Set("Content-Type", "application/json")
+		}
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,379 @@
+// Package openaitest provides a mock of the OpenAI API for tests, which
+// runs offline on an httptest.Server.
+//
+//	server := openaitest.NewServer()
+//	defer server.Close()
+//
+//	server.Enqueue(chat.Endpoint, openaitest.RateLimited(time.Second), openaitest.ChatResponse("Hi!"))
+//	resp, err := chat.MakeRequestWithClient(server.Client(), request, nil)
+//
+//	var sent chat.Request
+//	server.ExpectRequest(t, chat.Endpoint).Decode(&sent)
+//
+// Unless scripted otherwise, the server emulates the chat, completions,
+// embeddings, moderations, models, files, fine-tuning, images and audio
+// endpoints with deterministic responses. Requests are validated much like
+// the API would, and files and fine-tuning jobs are kept in memory, so that
+// a file which was uploaded may later be listed, retrieved and deleted.
+//
+// Responses may be scripted per endpoint, either once with Enqueue or
+// for every request with Handle, such as to inject errors, rate limits,
+// latency or streamed chunks. Every request received is recorded for
+// later assertions.
+package openaitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	auth "github.com/Kardbord/gopenai/authentication"
+	"github.com/Kardbord/gopenai/common"
+	"github.com/Kardbord/gopenai/finetuning"
+)
+
+// AnyEndpoint may be passed to Enqueue or Handle in place of an
+// endpoint, to script the responses to requests to every endpoint.
+const AnyEndpoint = "*"
+
+// The API key of the clients returned by Server.Client,
+// unless another is required with RequireAPIKey.
+const APIKey = "sk-openaitest"
+
+// The path prefix of the mocked API endpoints.
+const apiPrefix = "/" + common.APIVersion + "/"
+
+// A Request is a request received by a Server.
+type Request struct {
+	Method string
+
+	// The path of the request relative to the API's base URL,
+	// such as "chat/completions" or "files/file-1/content".
+	Endpoint string
+
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+
+	form *multipart.Form
+}
+
+// Decode decodes the JSON body of the request into v.
+func (r *Request) Decode(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Form parses the multipart form body of the request.
+func (r *Request) Form() (*multipart.Form, error) {
+	if r.form != nil {
+		return r.form, nil
+	}
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+	if mediaType != "multipart/form-data" {
+		return nil, fmt.Errorf("request body is %s, not multipart/form-data", mediaType)
+	}
+	r.form, err = multipart.NewReader(bytes.NewReader(r.Body), params["boundary"]).ReadForm(32 << 20)
+	return r.form, err
+}
+
+// FormValue returns the first value of the named field of
+// a multipart form body, or the empty string if there is none.
+func (r *Request) FormValue(key string) string {
+	form, err := r.Form()
+	if err != nil || len(form.Value[key]) == 0 {
+		return ""
+	}
+	return form.Value[key][0]
+}
+
+// FormFile returns the name and content of the named file of a multipart form body.
+func (r *Request) FormFile(key string) (string, []byte, error) {
+	form, err := r.Form()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(form.File[key]) == 0 {
+		return "", nil, fmt.Errorf("no %s file in form", key)
+	}
+	header := form.File[key][0]
+	file, err := header.Open()
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	return header.Filename, data, err
+}
+
+// A HandlerFunc returns the response to a request.
+type HandlerFunc func(r *Request) Response
+
+// A Server is a mock of the OpenAI API. See the package documentation.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	handlers map[string]HandlerFunc
+	queued   map[string][]Response
+	requests []*Request
+	latency  time.Duration
+	apiKey   string
+	ids      int
+
+	files     map[string]*storedFile
+	fileOrder []string
+	jobs      map[string]*finetuning.FineTune
+	jobOrder  []string
+}
+
+// NewServer starts a Server. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{}
+	s.reset()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *Server) reset() {
+	s.handlers = map[string]HandlerFunc{}
+	s.queued = map[string][]Response{}
+	s.requests = nil
+	s.latency = 0
+	s.apiKey = ""
+	s.files = map[string]*storedFile{}
+	s.fileOrder = nil
+	s.jobs = map[string]*finetuning.FineTune{}
+	s.jobOrder = nil
+}
+
+// Reset discards every scripted response, recorded request, uploaded file
+// and fine-tuning job, and restores the default latency and API key.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reset()
+}
+
+// BaseURL returns the base URL of the mocked API, which replaces common.BaseURL.
+func (s *Server) BaseURL() string {
+	return s.URL + apiPrefix
+}
+
+// Client returns a client which sends requests to the server, configured
+// with the given options. Its API key is APIKey, or the key required with
+// RequireAPIKey.
+func (s *Server) Client(opts ...common.ClientOption) *common.Client {
+	s.mu.Lock()
+	key := s.apiKey
+	s.mu.Unlock()
+	if len(key) == 0 {
+		key = APIKey
+	}
+	return common.NewClient(append([]common.ClientOption{
+		common.WithAPIKey(key),
+		common.WithBaseURL(s.BaseURL()),
+		common.WithHTTPClient(s.Server.Client()),
+	}, opts...)...)
+}
+
+// RequireAPIKey makes the server reject requests which are not
+// authenticated with key, as the API would. By default, any key is accepted.
+func (s *Server) RequireAPIKey(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiKey = key
+}
+
+// SetLatency delays every response by d, in addition to the Delay of
+// scripted responses.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Enqueue scripts the responses to the next requests to endpoint, which
+// may be relative to the API's base URL, such as "chat/completions", or an
+// endpoint constant, such as chat.Endpoint. Each response is sent once, in
+// order, after which requests are handled as before. Responses enqueued for
+// AnyEndpoint are sent to requests to any endpoint without its own.
+func (s *Server) Enqueue(endpoint string, responses ...Response) {
+	endpoint = normalizeEndpoint(endpoint)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queued[endpoint] = append(s.queued[endpoint], responses...)
+}
+
+// Handle replaces the emulation of endpoint with handler, which then
+// responds to every request to it which has no enqueued response.
+// Passing a nil handler restores the emulation.
+func (s *Server) Handle(endpoint string, handler HandlerFunc) {
+	endpoint = normalizeEndpoint(endpoint)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if handler == nil {
+		delete(s.handlers, endpoint)
+		return
+	}
+	s.handlers[endpoint] = handler
+}
+
+// Requests returns every request received, in order.
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Request(nil), s.requests...)
+}
+
+// RequestsTo returns every request received for endpoint, in order.
+func (s *Server) RequestsTo(endpoint string) []*Request {
+	endpoint = normalizeEndpoint(endpoint)
+	requests := []*Request{}
+	for _, r := range s.Requests() {
+		if r.Endpoint == endpoint {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
+
+// LastRequest returns the last request received, or nil if there is none.
+func (s *Server) LastRequest() *Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return nil
+	}
+	return s.requests[len(s.requests)-1]
+}
+
+// ExpectRequest returns the last request received for endpoint,
+// failing the test immediately if there is none.
+func (s *Server) ExpectRequest(t testing.TB, endpoint string) *Request {
+	t.Helper()
+	requests := s.RequestsTo(endpoint)
+	if len(requests) == 0 {
+		t.Fatalf("openaitest: no request received for %s", normalizeEndpoint(endpoint))
+	}
+	return requests[len(requests)-1]
+}
+
+// normalizeEndpoint returns endpoint relative to the API's base URL.
+func normalizeEndpoint(endpoint string) string {
+	endpoint = strings.TrimPrefix(endpoint, common.BaseURL)
+	endpoint = strings.TrimPrefix(endpoint, apiPrefix)
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+	return strings.Trim(endpoint, "/")
+}
+
+func (s *Server) nextID(prefix string) string {
+	s.ids++
+	return fmt.Sprintf("%s-openaitest%d", prefix, s.ids)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if strings.HasPrefix(req.URL.Path, imagePrefix) {
+		// Generated images are served outside the API.
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(mockPNG)
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r := &Request{
+		Method:   req.Method,
+		Endpoint: normalizeEndpoint(req.URL.Path),
+		URL:      req.URL,
+		Header:   req.Header.Clone(),
+		Body:     body,
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, r)
+	requestID := s.nextID("req")
+	latency := s.latency
+	var resp Response
+	var handler HandlerFunc
+	ok := true
+	switch {
+	case !strings.HasPrefix(req.URL.Path, apiPrefix):
+		resp = unknownURL(r)
+	case len(s.apiKey) != 0 && req.Header.Get(auth.AuthHeaderKey) != auth.AuthHeaderPrefix+s.apiKey:
+		resp = Error(http.StatusUnauthorized, "invalid_request_error", "invalid_api_key", "Incorrect API key provided.")
+	default:
+		// Queued responses are only consumed by requests which reach the API.
+		if resp, ok = s.dequeue(r.Endpoint); !ok {
+			handler = s.handlers[r.Endpoint]
+		}
+	}
+	s.mu.Unlock()
+
+	switch {
+	case ok:
+	case handler != nil:
+		resp = handler(r)
+	default:
+		resp = s.emulate(r)
+	}
+
+	if delay := latency + resp.Delay; delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return
+		}
+	}
+	resp.write(w, requestID)
+}
+
+func (s *Server) dequeue(endpoint string) (Response, bool) {
+	for _, key := range []string{endpoint, AnyEndpoint} {
+		if queue := s.queued[key]; len(queue) != 0 {
+			s.queued[key] = queue[1:]
+			return queue[0], true
+		}
+	}
+	return Response{}, false
+}
+
+// Main runs the tests of m against the live API if the OPENAI_API_KEY
+// environment variable is set, and otherwise against a Server which
+// replaces the default client, so that they also run offline. It returns
+// the exit code to pass to os.Exit from TestMain.
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(openaitest.Main(m))
+//	}
+func Main(m *testing.M) int {
+	if key := os.Getenv(auth.APIKeyEnvVar); len(key) != 0 {
+		auth.SetAPIKey(key)
+		return m.Run()
+	}
+	server := NewServer()
+	defer server.Close()
+	common.SetDefaultClient(server.Client())
+	defer common.SetDefaultClient(nil)
+	return m.Run()
+}
@@ -0,0 +1,216 @@
+package openaitest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/common"
+	"github.com/Kardbord/gopenai/embeddings"
+	"github.com/Kardbord/gopenai/files"
+	"github.com/Kardbord/gopenai/finetuning"
+	"github.com/Kardbord/gopenai/models"
+	"github.com/Kardbord/gopenai/openaitest"
+)
+
+var request = &chat.Request{
+	Model:    "gpt-4o-mini",
+	Messages: []chat.Chat{{Role: chat.UserRole, Content: "Hello"}},
+}
+
+func TestScriptedResponses(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	client := server.Client(common.WithRetryPolicy(common.RetryPolicy{MaxAttempts: 2}))
+
+	server.Enqueue(chat.Endpoint, openaitest.RateLimited(time.Millisecond), openaitest.ChatResponse("Scripted"))
+	resp, err := chat.MakeRequestWithClient(client, request, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Choices[0].Message.Text() != "Scripted" {
+		t.Fatalf("unexpected content: %q", resp.Choices[0].Message.Text())
+	}
+	if n := len(server.RequestsTo(chat.Endpoint)); n != 2 {
+		t.Fatalf("expected the rate limited request to be retried, got %d requests", n)
+	}
+
+	// Once the queue is empty, requests are emulated again.
+	resp, err = chat.MakeRequestWithClient(client, request, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Choices[0].Message.Text() != openaitest.DefaultContent {
+		t.Fatalf("unexpected content: %q", resp.Choices[0].Message.Text())
+	}
+
+	sent := chat.Request{}
+	if err := server.ExpectRequest(t, chat.Endpoint).Decode(&sent); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Model != request.Model {
+		t.Fatalf("unexpected request: %+v", sent)
+	}
+
+	server.Handle(openaitest.AnyEndpoint, func(r *openaitest.Request) openaitest.Response {
+		return openaitest.Error(http.StatusInternalServerError, "server_error", "", "Injected")
+	})
+	server.Handle(chat.Endpoint, func(r *openaitest.Request) openaitest.Response {
+		return openaitest.Error(http.StatusBadRequest, "invalid_request_error", "", "Injected")
+	})
+	_, err = chat.MakeRequestWithClient(client, request, nil)
+	apiErr := &common.APIError{}
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected the injected error, got %v", err)
+	}
+}
+
+func TestAPIKeyAndLatency(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	server.RequireAPIKey("sk-required")
+
+	_, err := models.MakeListModelsRequestWithClient(common.NewClient(common.WithBaseURL(server.BaseURL())), nil)
+	apiErr := &common.APIError{}
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+	if _, err := models.MakeListModelsRequestWithClient(server.Client(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unauthorized requests do not consume queued responses.
+	server.Enqueue(chat.Endpoint, openaitest.ChatResponse("Scripted"))
+	if _, err := chat.MakeRequestWithClient(common.NewClient(common.WithBaseURL(server.BaseURL())), request, nil); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected an unauthorized error, got %v", err)
+	}
+	resp, err := chat.MakeRequestWithClient(server.Client(), request, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Choices[0].Message.Text() != "Scripted" {
+		t.Fatalf("expected the queued response, got %q", resp.Choices[0].Message.Text())
+	}
+
+	server.SetLatency(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = models.MakeListModelsRequestWithClientContext(ctx, server.Client(), nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the request to time out, got %v", err)
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+
+	streamed := *request
+	streamed.StreamOptions = &chat.StreamOptions{IncludeUsage: true}
+	stream, err := chat.MakeStreamingRequestWithClient(server.Client(), &streamed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := chat.Accumulate(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Choices[0].Message.Text() != openaitest.DefaultContent || resp.Usage.TotalTokens == 0 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestStructuredOutputs(t *testing.T) {
+	type Answer struct {
+		Value  int      `json:"value"`
+		Reason string   `json:"reason"`
+		Steps  []string `json:"steps"`
+	}
+	server := openaitest.NewServer()
+	defer server.Close()
+
+	answer, _, err := chat.MakeStructuredRequestWithClient[Answer](server.Client(), request, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer == nil || answer.Steps == nil {
+		t.Fatalf("expected an answer matching the schema, got %+v", answer)
+	}
+}
+
+func TestEmbeddings(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+
+	resp, err := embeddings.MakeRequestWithClient(server.Client(), &embeddings.Request{
+		Model: "text-embedding-3-small",
+		Input: []string{"a", "b"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 2 || len(resp.Data[0].Embedding) != 1536 {
+		t.Fatalf("unexpected response: %d embeddings", len(resp.Data))
+	}
+	expected := openaitest.Embedding("a", 1536)
+	for i, v := range resp.Data[0].Embedding {
+		if v != expected[i] {
+			t.Fatalf("expected deterministic embeddings, got %v at %d", v, i)
+		}
+	}
+}
+
+func TestFilesAndFineTuning(t *testing.T) {
+	server := openaitest.NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	file, err := files.MakeUploadRequestWithClient(client, &files.UploadRequest{
+		Purpose:  "fine-tune",
+		Filename: "data.jsonl",
+		Filepath: "../files/test_files/testdata.jsonl",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := files.MakeListRequestWithClient(client, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Data) != 1 || list.Data[0].ID != file.ID {
+		t.Fatalf("expected the uploaded file to be listed, got %+v", list.Data)
+	}
+
+	job, err := finetuning.MakeCreationRequestWithClient(client, &finetuning.CreationRequest{
+		Model:        "gpt-4o-mini",
+		TrainingFile: file.ID,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for job.Status != "succeeded" {
+		if job, err = finetuning.MakeRetrieveRequestWithClient(client, job.ID, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := models.MakeRetrieveModelRequestWithClient(client, job.FineTunedModel, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := files.MakeDeleteRequestWithClient(client, file.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, err = files.MakeRetrieveRequestWithClient(client, file.ID, nil)
+	apiErr := &common.APIError{}
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected the deleted file to be gone, got %v", err)
+	}
+
+	server.Reset()
+	if len(server.Requests()) != 0 {
+		t.Fatal("expected Reset to discard recorded requests")
+	}
+}
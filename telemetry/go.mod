@@ -0,0 +1,18 @@
+module github.com/Kardbord/gopenai/telemetry
+
+go 1.21
+
+require (
+	github.com/Kardbord/gopenai v0.0.0-20261017034838-e9323d9af1e9
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/metric v1.24.0
+	go.opentelemetry.io/otel/sdk v1.24.0
+	go.opentelemetry.io/otel/sdk/metric v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+)
@@ -0,0 +1,580 @@
+// Package telemetry instruments requests sent by a common.Client with
+// [OpenTelemetry]. Each request is traced by a client span, whose
+// attributes follow the [GenAI semantic conventions], such as the model,
+// operation, token usage and finish reasons. The duration and token usage
+// of each request are also recorded as metrics, and the trace context is
+// propagated to the API in the request headers.
+//
+//	client := gopenai.NewClient(
+//		common.WithMiddleware(telemetry.Middleware()),
+//	)
+//
+// By default, the global TracerProvider, MeterProvider and propagator
+// registered with the otel package are used.
+//
+// Since the Middleware is applied to every attempt of a request, a request
+// retried by the client's RetryPolicy is traced by one span per attempt.
+//
+// [OpenTelemetry]: https://opentelemetry.io/
+// [GenAI semantic conventions]: https://opentelemetry.io/docs/specs/semconv/gen-ai/
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kardbord/gopenai/common"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The name of the instrumentation scope of the tracer and meter.
+const ScopeName = "github.com/Kardbord/gopenai/telemetry"
+
+// Attribute keys from the GenAI semantic conventions.
+const (
+	AttrSystem                = attribute.Key("gen_ai.system")
+	AttrOperationName         = attribute.Key("gen_ai.operation.name")
+	AttrRequestModel          = attribute.Key("gen_ai.request.model")
+	AttrRequestMaxTokens      = attribute.Key("gen_ai.request.max_tokens")
+	AttrRequestTemperature    = attribute.Key("gen_ai.request.temperature")
+	AttrRequestTopP           = attribute.Key("gen_ai.request.top_p")
+	AttrResponseID            = attribute.Key("gen_ai.response.id")
+	AttrResponseModel         = attribute.Key("gen_ai.response.model")
+	AttrResponseFinishReasons = attribute.Key("gen_ai.response.finish_reasons")
+	AttrUsageInputTokens      = attribute.Key("gen_ai.usage.input_tokens")
+	AttrUsageOutputTokens     = attribute.Key("gen_ai.usage.output_tokens")
+	AttrTokenType             = attribute.Key("gen_ai.token.type")
+	AttrErrorType             = attribute.Key("error.type")
+	AttrServerAddress         = attribute.Key("server.address")
+	AttrServerPort            = attribute.Key("server.port")
+	AttrHTTPStatusCode        = attribute.Key("http.response.status_code")
+	AttrOpenAIRequestID       = attribute.Key("openai.request.id")
+)
+
+// Values of AttrOperationName.
+const (
+	OperationChat           = "chat"
+	OperationTextCompletion = "text_completion"
+	OperationEmbeddings     = "embeddings"
+)
+
+// The names of the metrics recorded by the Middleware.
+const (
+	MetricOperationDuration = "gen_ai.client.operation.duration"
+	MetricTokenUsage        = "gen_ai.client.token.usage"
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// An Option configures the Middleware.
+type Option func(*config)
+
+// WithTracerProvider sets the TracerProvider used to create spans.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithMeterProvider sets the MeterProvider used to record metrics.
+func WithMeterProvider(provider metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = provider
+	}
+}
+
+// WithPropagator sets the propagator used to inject
+// the trace context into request headers.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
+
+type instrumentation struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	duration   metric.Float64Histogram
+	tokens     metric.Int64Histogram
+}
+
+// Middleware returns a common.Middleware which instruments every request
+// with OpenTelemetry, as configured by the given options.
+func Middleware(opts ...Option) common.Middleware {
+	cfg := config{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+	if cfg.tracerProvider == nil {
+		cfg.tracerProvider = otel.GetTracerProvider()
+	}
+	if cfg.meterProvider == nil {
+		cfg.meterProvider = otel.GetMeterProvider()
+	}
+	if cfg.propagator == nil {
+		cfg.propagator = otel.GetTextMapPropagator()
+	}
+
+	meter := cfg.meterProvider.Meter(ScopeName)
+	inst := &instrumentation{
+		tracer:     cfg.tracerProvider.Tracer(ScopeName),
+		propagator: cfg.propagator,
+	}
+	var err error
+	inst.duration, err = meter.Float64Histogram(MetricOperationDuration,
+		metric.WithDescription("GenAI operation duration."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.01, 0.02, 0.04, 0.08, 0.16, 0.32, 0.64, 1.28, 2.56, 5.12, 10.24, 20.48, 40.96, 81.92),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+	inst.tokens, err = meter.Int64Histogram(MetricTokenUsage,
+		metric.WithDescription("Measures number of input and output tokens used."),
+		metric.WithUnit("{token}"),
+		metric.WithExplicitBucketBoundaries(1, 4, 16, 64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return func(next common.RoundTripFunc) common.RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			return inst.roundTrip(next, req)
+		}
+	}
+}
+
+// The fields of a request body which are recorded.
+type requestFields struct {
+	Model       string   `json:"model"`
+	MaxTokens   *int64   `json:"max_tokens"`
+	Temperature *float64 `json:"temperature"`
+	TopP        *float64 `json:"top_p"`
+}
+
+// The fields of a response body, or of a streamed chunk, which are recorded.
+type responseFields struct {
+	ID      string                `json:"id"`
+	Model   string                `json:"model"`
+	Usage   *common.ResponseUsage `json:"usage"`
+	Choices []struct {
+		FinishReason *string `json:"finish_reason"`
+	} `json:"choices"`
+	Error *common.ResponseError `json:"error"`
+}
+
+// The state of a single traced request.
+type operation struct {
+	inst          *instrumentation
+	ctx           context.Context
+	span          trace.Span
+	start         time.Time
+	metricAttrs   []attribute.KeyValue
+	responseModel string
+	finishReasons []string
+	usage         *common.ResponseUsage
+	once          sync.Once
+}
+
+func (inst *instrumentation) roundTrip(next common.RoundTripFunc, req *http.Request) (*http.Response, error) {
+	fields := requestFields{}
+	if req.GetBody != nil && req.Body != nil && req.Body != http.NoBody {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			json.Unmarshal(data, &fields)
+		}
+	}
+
+	opName := operationName(req.URL.Path)
+	attrs := []attribute.KeyValue{
+		AttrSystem.String("openai"),
+		AttrOperationName.String(opName),
+	}
+	if host := req.URL.Hostname(); len(host) != 0 {
+		attrs = append(attrs, AttrServerAddress.String(host))
+	}
+	if port := req.URL.Port(); len(port) != 0 {
+		if p, err := strconv.Atoi(port); err == nil {
+			attrs = append(attrs, AttrServerPort.Int(p))
+		}
+	}
+	if len(fields.Model) != 0 {
+		attrs = append(attrs, AttrRequestModel.String(fields.Model))
+	}
+	metricAttrs := append([]attribute.KeyValue{}, attrs...)
+	if fields.MaxTokens != nil {
+		attrs = append(attrs, AttrRequestMaxTokens.Int64(*fields.MaxTokens))
+	}
+	if fields.Temperature != nil {
+		attrs = append(attrs, AttrRequestTemperature.Float64(*fields.Temperature))
+	}
+	if fields.TopP != nil {
+		attrs = append(attrs, AttrRequestTopP.Float64(*fields.TopP))
+	}
+
+	spanName := opName
+	if len(fields.Model) != 0 {
+		spanName += " " + fields.Model
+	}
+	ctx, span := inst.tracer.Start(req.Context(), spanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	op := &operation{
+		inst:        inst,
+		ctx:         ctx,
+		span:        span,
+		start:       time.Now(),
+		metricAttrs: metricAttrs,
+	}
+
+	req = req.WithContext(ctx)
+	inst.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := next(req)
+	if err != nil {
+		op.finish("", err)
+		return resp, err
+	}
+
+	span.SetAttributes(AttrHTTPStatusCode.Int(resp.StatusCode))
+	if requestID := resp.Header.Get(common.RequestIDHeaderKey); len(requestID) != 0 {
+		span.SetAttributes(AttrOpenAIRequestID.String(requestID))
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	switch {
+	case strings.HasPrefix(contentType, "text/event-stream"):
+		resp.Body = &streamBody{body: resp.Body, op: op, status: resp.StatusCode}
+	case strings.HasPrefix(contentType, "application/json") || resp.StatusCode >= http.StatusBadRequest:
+		resp.Body = &jsonBody{body: resp.Body, op: op, status: resp.StatusCode}
+	default:
+		op.finish(statusErrorType(resp.StatusCode, ""), nil)
+	}
+	return resp, nil
+}
+
+// operationName derives the operation from the path of the endpoint.
+func operationName(path string) string {
+	path = strings.TrimSuffix(path, "/")
+	switch {
+	case strings.HasSuffix(path, "/chat/completions"):
+		return OperationChat
+	case strings.HasSuffix(path, "/completions"):
+		return OperationTextCompletion
+	case strings.HasSuffix(path, "/embeddings"):
+		return OperationEmbeddings
+	}
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.Index(path, "v1/"); i >= 0 {
+		path = path[i+len("v1/"):]
+	}
+	return path
+}
+
+// statusErrorType returns the error.type of an unsuccessful response,
+// preferring the error type from the response body.
+func statusErrorType(status int, errorType string) string {
+	if status < http.StatusBadRequest {
+		return ""
+	}
+	if len(errorType) != 0 {
+		return errorType
+	}
+	return strconv.Itoa(status)
+}
+
+// recordResponse records the fields of a response body or streamed chunk,
+// returning the type of the error it contains, if any.
+func (op *operation) recordResponse(data []byte) string {
+	fields := responseFields{}
+	if json.Unmarshal(data, &fields) != nil {
+		return ""
+	}
+	if len(fields.ID) != 0 {
+		op.span.SetAttributes(AttrResponseID.String(fields.ID))
+	}
+	if len(fields.Model) != 0 {
+		op.responseModel = fields.Model
+	}
+	for _, choice := range fields.Choices {
+		if choice.FinishReason != nil && len(*choice.FinishReason) != 0 {
+			op.finishReasons = append(op.finishReasons, *choice.FinishReason)
+		}
+	}
+	if fields.Usage != nil {
+		op.usage = fields.Usage
+	}
+	if fields.Error != nil {
+		if len(fields.Error.Type) != 0 {
+			return fields.Error.Type
+		}
+		return "error"
+	}
+	return ""
+}
+
+// finish ends the span and records the metrics of the operation.
+// The errorType is empty if the operation succeeded.
+func (op *operation) finish(errorType string, err error) {
+	op.once.Do(func() {
+		metricAttrs := op.metricAttrs
+		if len(op.responseModel) != 0 {
+			op.span.SetAttributes(AttrResponseModel.String(op.responseModel))
+			metricAttrs = append(metricAttrs, AttrResponseModel.String(op.responseModel))
+		}
+		if len(op.finishReasons) != 0 {
+			op.span.SetAttributes(AttrResponseFinishReasons.StringSlice(op.finishReasons))
+		}
+
+		if err != nil {
+			op.span.RecordError(err)
+			if len(errorType) == 0 {
+				errorType = errType(err)
+			}
+		}
+		if len(errorType) != 0 {
+			op.span.SetAttributes(AttrErrorType.String(errorType))
+			if err != nil {
+				op.span.SetStatus(codes.Error, err.Error())
+			} else {
+				op.span.SetStatus(codes.Error, errorType)
+			}
+			metricAttrs = append(metricAttrs, AttrErrorType.String(errorType))
+		}
+
+		if op.usage != nil {
+			op.span.SetAttributes(
+				AttrUsageInputTokens.Int64(int64(op.usage.PromptTokens)),
+				AttrUsageOutputTokens.Int64(int64(op.usage.CompletionTokens)),
+			)
+			if op.inst.tokens != nil {
+				op.inst.tokens.Record(op.ctx, int64(op.usage.PromptTokens), metric.WithAttributes(append(metricAttrs, AttrTokenType.String("input"))...))
+				op.inst.tokens.Record(op.ctx, int64(op.usage.CompletionTokens), metric.WithAttributes(append(metricAttrs, AttrTokenType.String("output"))...))
+			}
+		}
+		if op.inst.duration != nil {
+			op.inst.duration.Record(op.ctx, time.Since(op.start).Seconds(), metric.WithAttributes(metricAttrs...))
+		}
+		op.span.End()
+	})
+}
+
+// errType returns the error.type of an error which prevented a response
+// from being received.
+func errType(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	}
+	return "_OTHER"
+}
+
+// A streamBody records the chunks of a streamed response as they are
+// read, finishing the operation once the stream has been read to the
+// end or closed.
+type streamBody struct {
+	body      io.ReadCloser
+	op        *operation
+	status    int
+	pending   []byte
+	errorType string
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.pending = append(b.pending, p[:n]...)
+	for {
+		i := bytes.IndexByte(b.pending, '\n')
+		if i < 0 {
+			break
+		}
+		b.line(b.pending[:i])
+		b.pending = b.pending[i+1:]
+	}
+	if err == io.EOF {
+		b.line(b.pending)
+		b.op.finish(statusErrorType(b.status, b.errorType), nil)
+	} else if err != nil {
+		b.op.finish("", err)
+	}
+	return n, err
+}
+
+func (b *streamBody) line(line []byte) {
+	data, ok := bytes.CutPrefix(bytes.TrimRight(line, "\r"), []byte("data:"))
+	if !ok {
+		return
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == common.StreamDoneData {
+		return
+	}
+	if errorType := b.op.recordResponse(data); len(errorType) != 0 && len(b.errorType) == 0 {
+		b.errorType = errorType
+	}
+}
+
+func (b *streamBody) Close() error {
+	err := b.body.Close()
+	errorType := b.errorType
+	if len(errorType) == 0 && b.status >= http.StatusBadRequest {
+		errorType = strconv.Itoa(b.status)
+	}
+	b.op.finish(errorType, nil)
+	return err
+}
+
+// The number of bytes kept from the start and from the end of a JSON
+// response body, from which its fields are recorded.
+const keptBytes = 4096
+
+// A jsonBody records the fields of a JSON response body, finishing the
+// operation once the body has been read to the end or closed. Only the
+// start and the end of a large body are kept, since the ID and model are
+// reported at the start of a response, and the finish reasons and usage
+// at its end.
+type jsonBody struct {
+	body      io.ReadCloser
+	op        *operation
+	status    int
+	head      []byte
+	tail      []byte
+	truncated bool
+	once      sync.Once
+}
+
+func (b *jsonBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	data := p[:n]
+	if len(b.head) < keptBytes {
+		i := min(keptBytes-len(b.head), len(data))
+		b.head = append(b.head, data[:i]...)
+		data = data[i:]
+	}
+	b.tail = append(b.tail, data...)
+	if len(b.tail) > 2*keptBytes {
+		b.tail = append(b.tail[:0], b.tail[len(b.tail)-keptBytes:]...)
+		b.truncated = true
+	}
+	if err == io.EOF {
+		b.finish(nil)
+	} else if err != nil {
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *jsonBody) Close() error {
+	err := b.body.Close()
+	b.finish(nil)
+	return err
+}
+
+func (b *jsonBody) finish(err error) {
+	b.once.Do(func() {
+		if err != nil {
+			b.op.finish("", err)
+			return
+		}
+		var errorType string
+		if b.truncated {
+			b.op.recordTruncatedResponse(b.head, b.tail)
+		} else {
+			errorType = b.op.recordResponse(append(b.head, b.tail...))
+		}
+		b.op.finish(statusErrorType(b.status, errorType), nil)
+	})
+}
+
+// recordTruncatedResponse records the fields of a JSON response body of
+// which only the start and the end were kept.
+func (op *operation) recordTruncatedResponse(head, tail []byte) {
+	// The top-level fields before the first which does not fit in head.
+	decoder := json.NewDecoder(bytes.NewReader(head))
+	if t, err := decoder.Token(); err == nil && t == json.Delim('{') {
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				break
+			}
+			var value json.RawMessage
+			if decoder.Decode(&value) != nil {
+				break
+			}
+			switch key {
+			case "id":
+				var id string
+				if json.Unmarshal(value, &id) == nil && len(id) != 0 {
+					op.span.SetAttributes(AttrResponseID.String(id))
+				}
+			case "model":
+				json.Unmarshal(value, &op.responseModel)
+			}
+		}
+	}
+
+	for _, value := range valuesOf(tail, "finish_reason") {
+		var reason string
+		if json.Unmarshal(value, &reason) == nil && len(reason) != 0 {
+			op.finishReasons = append(op.finishReasons, reason)
+		}
+	}
+	if values := valuesOf(tail, "usage"); len(values) != 0 {
+		json.Unmarshal(values[len(values)-1], &op.usage)
+	}
+}
+
+// valuesOf returns the values of every complete field named key in data,
+// which may be a fragment of a JSON document.
+func valuesOf(data []byte, key string) []json.RawMessage {
+	values := []json.RawMessage{}
+	quoted := []byte(`"` + key + `"`)
+	for i := 0; ; {
+		j := bytes.Index(data[i:], quoted)
+		if j < 0 {
+			return values
+		}
+		i += j + len(quoted)
+		// A quote within a string is escaped, so an unescaped quoted
+		// key followed by a colon is a field name.
+		if i > len(quoted) && data[i-len(quoted)-1] == '\\' {
+			continue
+		}
+		rest, ok := bytes.CutPrefix(bytes.TrimSpace(data[i:]), []byte(":"))
+		if !ok {
+			continue
+		}
+		var value json.RawMessage
+		if json.NewDecoder(bytes.NewReader(rest)).Decode(&value) == nil {
+			values = append(values, value)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package telemetry_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/common"
+	"github.com/Kardbord/gopenai/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type testTelemetry struct {
+	spans  *tracetest.SpanRecorder
+	reader *sdkmetric.ManualReader
+	client *common.Client
+	tp     *sdktrace.TracerProvider
+}
+
+func newTestTelemetry(server *httptest.Server) *testTelemetry {
+	tt := &testTelemetry{
+		spans:  tracetest.NewSpanRecorder(),
+		reader: sdkmetric.NewManualReader(),
+	}
+	tt.tp = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(tt.spans))
+	tt.client = common.NewClient(
+		common.WithAPIKey("key"),
+		common.WithBaseURL(server.URL+"/v1"),
+		common.WithMiddleware(telemetry.Middleware(
+			telemetry.WithTracerProvider(tt.tp),
+			telemetry.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(tt.reader))),
+			telemetry.WithPropagator(propagation.TraceContext{}),
+		)),
+	)
+	return tt
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestChatSpan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.Header.Get("traceparent")) == 0 {
+			t.Error("trace context was not propagated")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set(common.RequestIDHeaderKey, "req_123")
+		w.Write([]byte(`{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Hi"}}],"usage":{"prompt_tokens":9,"completion_tokens":2,"total_tokens":11}}`))
+	}))
+	defer server.Close()
+	tt := newTestTelemetry(server)
+
+	ctx, parent := tt.tp.Tracer("test").Start(context.Background(), "parent")
+	maxTokens := int64(16)
+	_, err := chat.MakeRequestWithClientContext(ctx, tt.client, &chat.Request{
+		Model:     "gpt-4o",
+		Messages:  []chat.Chat{{Role: chat.UserRole, Content: "Hello"}},
+		MaxTokens: &maxTokens,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := tt.spans.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "chat gpt-4o" || span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("unexpected span %q with parent %v", span.Name(), span.Parent())
+	}
+	attrs := attributes(span)
+	for key, expected := range map[attribute.Key]attribute.Value{
+		telemetry.AttrSystem:                attribute.StringValue("openai"),
+		telemetry.AttrOperationName:         attribute.StringValue("chat"),
+		telemetry.AttrRequestModel:          attribute.StringValue("gpt-4o"),
+		telemetry.AttrRequestMaxTokens:      attribute.Int64Value(16),
+		telemetry.AttrResponseID:            attribute.StringValue("chatcmpl-1"),
+		telemetry.AttrResponseModel:         attribute.StringValue("gpt-4o-2024-08-06"),
+		telemetry.AttrResponseFinishReasons: attribute.StringSliceValue([]string{"stop"}),
+		telemetry.AttrUsageInputTokens:      attribute.Int64Value(9),
+		telemetry.AttrUsageOutputTokens:     attribute.Int64Value(2),
+		telemetry.AttrOpenAIRequestID:       attribute.StringValue("req_123"),
+	} {
+		if attrs[key] != expected {
+			t.Errorf("expected %s to be %v, got %v", key, expected.Emit(), attrs[key].Emit())
+		}
+	}
+
+	metrics := metricdata.ResourceMetrics{}
+	if err = tt.reader.Collect(context.Background(), &metrics); err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, scope := range metrics.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			found[m.Name] = true
+			if m.Name == telemetry.MetricTokenUsage {
+				points := m.Data.(metricdata.Histogram[int64]).DataPoints
+				if len(points) != 2 {
+					t.Errorf("expected input and output token usage, got %d points", len(points))
+				}
+				for _, p := range points {
+					tokenType, _ := p.Attributes.Value(telemetry.AttrTokenType)
+					if (tokenType.AsString() == "input" && p.Sum != 9) || (tokenType.AsString() == "output" && p.Sum != 2) {
+						t.Errorf("unexpected %s token usage %d", tokenType.AsString(), p.Sum)
+					}
+				}
+			}
+		}
+	}
+	if !found[telemetry.MetricOperationDuration] || !found[telemetry.MetricTokenUsage] {
+		t.Fatalf("missing metrics: %v", found)
+	}
+}
+
+func TestLargeResponseSpan(t *testing.T) {
+	content := strings.Repeat(`a \"usage\": \"finish_reason\": `, 1000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"chatcmpl-1","model":"gpt-4o-2024-08-06","choices":[` +
+			`{"index":0,"message":{"role":"assistant","content":"` + content + `"},"finish_reason":"length"},` +
+			`{"index":1,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],` +
+			"\n" + `"usage": {"prompt_tokens":9,"completion_tokens":2,"total_tokens":11}}`))
+	}))
+	defer server.Close()
+	tt := newTestTelemetry(server)
+
+	resp, err := chat.MakeRequestWithClient(tt.client, &chat.Request{
+		Model:    "gpt-4o",
+		Messages: []chat.Chat{{Role: chat.UserRole, Content: "Hello"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Choices) != 2 || resp.Choices[0].Message.Text() != strings.ReplaceAll(content, `\"`, `"`) {
+		t.Fatal("expected the response body to be unaffected")
+	}
+
+	spans := tt.spans.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attributes(spans[0])
+	for key, expected := range map[attribute.Key]attribute.Value{
+		telemetry.AttrResponseID:            attribute.StringValue("chatcmpl-1"),
+		telemetry.AttrResponseModel:         attribute.StringValue("gpt-4o-2024-08-06"),
+		telemetry.AttrResponseFinishReasons: attribute.StringSliceValue([]string{"length", "stop"}),
+		telemetry.AttrUsageInputTokens:      attribute.Int64Value(9),
+		telemetry.AttrUsageOutputTokens:     attribute.Int64Value(2),
+	} {
+		if attrs[key] != expected {
+			t.Errorf("expected %s to be %v, got %v", key, expected.Emit(), attrs[key].Emit())
+		}
+	}
+}
+
+func TestStreamingSpan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"id\":\"chatcmpl-2\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n" +
+			"data: {\"id\":\"chatcmpl-2\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"length\"}]}\n\n" +
+			"data: {\"id\":\"chatcmpl-2\",\"model\":\"gpt-4o\",\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":1,\"total_tokens\":6}}\n\n" +
+			"data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+	tt := newTestTelemetry(server)
+
+	stream, err := chat.MakeStreamingRequestWithClient(tt.client, &chat.Request{
+		Model:    "gpt-4o",
+		Messages: []chat.Chat{{Role: chat.UserRole, Content: "Hello"}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tt.spans.Ended()) != 0 {
+		t.Fatal("span ended before the stream was read")
+	}
+	if _, err = chat.Accumulate(stream); err != nil {
+		t.Fatal(err)
+	}
+	stream.Close()
+
+	spans := tt.spans.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attributes(spans[0])
+	if attrs[telemetry.AttrUsageOutputTokens] != attribute.Int64Value(1) ||
+		attrs[telemetry.AttrResponseFinishReasons] != attribute.StringSliceValue([]string{"length"}) {
+		t.Fatalf("unexpected attributes: %v", attrs)
+	}
+}
+
+func TestErrorSpan(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"bad","type":"invalid_request_error"}}`))
+	}))
+	defer server.Close()
+	tt := newTestTelemetry(server)
+
+	_, err := chat.MakeRequestWithClient(tt.client, &chat.Request{Model: "gpt-4o"}, nil)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	span := tt.spans.Ended()[0]
+	if span.Status().Code != codes.Error || attributes(span)[telemetry.AttrErrorType] != attribute.StringValue("invalid_request_error") {
+		t.Fatalf("unexpected span status %v and attributes %v", span.Status(), span.Attributes())
+	}
+}
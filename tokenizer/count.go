@@ -0,0 +1,83 @@
+package tokenizer
+
+import (
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/embeddings"
+)
+
+const (
+	// The tokens which wrap every message in a chat request.
+	tokensPerMessage = 3
+
+	// The additional token consumed by a message with a name.
+	tokensPerName = 1
+
+	// The tokens which prime every reply from the model.
+	tokensPerReply = 3
+
+	// The tokens consumed by an image, depending on its detail level. The
+	// cost of a high detail image depends on its size; this is the cost
+	// of a 1024x1024 image.
+	tokensPerLowDetailImage  = 85
+	tokensPerHighDetailImage = 765
+)
+
+// CountMessage returns the number of tokens message consumes in a chat
+// request, including the tokens which wrap every message. Images are
+// counted as if they were 1024x1024, unless their detail level is low.
+func (e *Encoding) CountMessage(message *chat.Chat) int {
+	tokens := tokensPerMessage + e.Count(message.Role)
+	if len(message.ContentParts) == 0 {
+		tokens += e.Count(message.Content)
+	}
+	for _, part := range message.ContentParts {
+		switch part.Type {
+		case chat.ContentPartText:
+			tokens += e.Count(part.Text)
+		case chat.ContentPartImageURL:
+			if part.ImageURL != nil && part.ImageURL.Detail == chat.ImageDetailLow {
+				tokens += tokensPerLowDetailImage
+			} else {
+				tokens += tokensPerHighDetailImage
+			}
+		}
+	}
+	if len(message.Name) != 0 {
+		tokens += tokensPerName + e.Count(message.Name)
+	}
+	for _, call := range message.ToolCalls {
+		tokens += e.Count(call.Function.Name) + e.Count(call.Function.Arguments)
+	}
+	return tokens
+}
+
+// CountMessages returns the number of prompt tokens a chat request
+// containing messages consumes, including the tokens which prime the
+// model's reply. Tool definitions are not counted.
+func (e *Encoding) CountMessages(messages []chat.Chat) int {
+	tokens := tokensPerReply
+	for i := range messages {
+		tokens += e.CountMessage(&messages[i])
+	}
+	return tokens
+}
+
+// MessageCounter returns a chat.TokenCounter which counts tokens with the
+// encoding, for use as the TokenCounter of a chat.Conversation.
+func (e *Encoding) MessageCounter() chat.TokenCounter {
+	return func(message *chat.Chat) int64 {
+		return int64(e.CountMessage(message))
+	}
+}
+
+// CountEmbeddingsInput returns the number of tokens in each input of an
+// embeddings request, which must not exceed the model's input limit, and
+// the total number of tokens the request consumes.
+func (e *Encoding) CountEmbeddingsInput(request *embeddings.Request) (total int, perInput []int) {
+	perInput = make([]int, len(request.Input))
+	for i, input := range request.Input {
+		perInput[i] = e.Count(input)
+		total += perInput[i]
+	}
+	return total, perInput
+}
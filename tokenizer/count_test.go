@@ -0,0 +1,52 @@
+package tokenizer_test
+
+import (
+	"testing"
+
+	"github.com/Kardbord/gopenai/chat"
+	"github.com/Kardbord/gopenai/embeddings"
+	"github.com/Kardbord/gopenai/tokenizer"
+)
+
+func TestCountMessages(t *testing.T) {
+	enc := newTestEncoding(t)
+
+	message := chat.Chat{Role: chat.UserRole, Content: "hello world", Name: "he"}
+	// 3 per message, 4 for the role, 3 for the content, and 1 + 1 for the name.
+	if n := enc.CountMessage(&message); n != 12 {
+		t.Fatalf("expected 12 tokens, got %d", n)
+	}
+
+	image := chat.NewMultipartMessage(chat.UserRole,
+		chat.NewTextPart("hello world"),
+		chat.NewImageURLPart("https://example.com/image.png", chat.ImageDetailLow),
+	)
+	if n := enc.CountMessage(&image); n != 3+4+3+85 {
+		t.Fatalf("expected %d tokens, got %d", 3+4+3+85, n)
+	}
+
+	if n := enc.CountMessages([]chat.Chat{message, image}); n != 3+12+95 {
+		t.Fatalf("expected %d tokens, got %d", 3+12+95, n)
+	}
+	if n := enc.MessageCounter()(&message); n != 12 {
+		t.Fatalf("expected 12 tokens, got %d", n)
+	}
+
+	total, perInput := enc.CountEmbeddingsInput(&embeddings.Request{Input: []string{"hello world", "he"}})
+	if total != 4 || len(perInput) != 2 || perInput[0] != 3 || perInput[1] != 1 {
+		t.Fatalf("unexpected counts %d, %v", total, perInput)
+	}
+}
+
+func TestCountMessagesForModel(t *testing.T) {
+	for _, model := range []string{"gpt-4", "gpt-4o"} {
+		enc, err := tokenizer.ForModel(model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 3 per message, 1 for the role, 2 for the content, and 3 to prime the reply.
+		if n := enc.CountMessages([]chat.Chat{{Role: chat.UserRole, Content: "hello world"}}); n != 9 {
+			t.Errorf("%s: expected 9 tokens, got %d", model, n)
+		}
+	}
+}
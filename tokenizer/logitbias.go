@@ -0,0 +1,101 @@
+package tokenizer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The range of values accepted by the API for a logit bias.
+const (
+	MinLogitBias = -100
+	MaxLogitBias = 100
+)
+
+// A LogitBias builds the LogitBias map of a chat or completions request
+// from words and phrases, rather than token IDs.
+//
+//	bias, err := tokenizer.NewLogitBias(enc).
+//		Add("Paris", -100).
+//		Add("London", 5).
+//		Build()
+//	request.LogitBias = bias
+//
+// Errors are deferred until Build, so that calls may be chained.
+type LogitBias struct {
+	enc  *Encoding
+	bias map[int]int64
+	err  error
+}
+
+// NewLogitBias creates an empty LogitBias which tokenizes text with enc.
+func NewLogitBias(enc *Encoding) *LogitBias {
+	return &LogitBias{enc: enc, bias: map[int]int64{}}
+}
+
+// NewLogitBiasForModel creates an empty LogitBias which tokenizes
+// text with the encoding used by the given model.
+func NewLogitBiasForModel(model string) (*LogitBias, error) {
+	enc, err := ForModel(model)
+	if err != nil {
+		return nil, err
+	}
+	return NewLogitBias(enc), nil
+}
+
+// Add biases every token of text, both as is and preceded by a space, as
+// words are usually tokenized along with the space before them. Text which
+// already starts with a space is only added as is.
+//
+// Text which is encoded as several tokens biases each of them, which also
+// affects other text containing the same tokens. If the same token is
+// biased more than once, the last bias applies.
+func (b *LogitBias) Add(text string, bias int64) *LogitBias {
+	b.AddExact(text, bias)
+	if !strings.HasPrefix(text, " ") {
+		b.AddExact(" "+text, bias)
+	}
+	return b
+}
+
+// AddExact biases every token of text, without adding a leading space.
+func (b *LogitBias) AddExact(text string, bias int64) *LogitBias {
+	if len(text) == 0 {
+		b.setErr(errors.New("empty text provided for logit bias"))
+		return b
+	}
+	for _, token := range b.enc.Encode(text) {
+		b.AddToken(token, bias)
+	}
+	return b
+}
+
+// AddToken biases a single token ID.
+func (b *LogitBias) AddToken(token int, bias int64) *LogitBias {
+	if bias < MinLogitBias || bias > MaxLogitBias {
+		b.setErr(fmt.Errorf("logit bias %d for token %d is outside the range [%d, %d]", bias, token, MinLogitBias, MaxLogitBias))
+		return b
+	}
+	b.bias[token] = bias
+	return b
+}
+
+func (b *LogitBias) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// Build returns the LogitBias map for a chat.Request or completions.Request,
+// or the first error encountered while adding text.
+func (b *LogitBias) Build() (map[string]int64, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	bias := make(map[string]int64, len(b.bias))
+	for token, value := range b.bias {
+		bias[strconv.Itoa(token)] = value
+	}
+	return bias, nil
+}
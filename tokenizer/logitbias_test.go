@@ -0,0 +1,66 @@
+package tokenizer_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Kardbord/gopenai/tokenizer"
+)
+
+func TestLogitBias(t *testing.T) {
+	enc := newTestEncoding(t)
+
+	bias, err := tokenizer.NewLogitBias(enc).
+		Add("world", -100).
+		AddExact("hell", 5).
+		AddToken(42, 1).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int64{
+		// "world" is encoded as "w", "or" and "ld", and " world" as one token.
+		"119": -100, "260": -100, "262": -100,
+		"263": -100,
+		"258": 5,
+		"42":  1,
+	}
+	if !reflect.DeepEqual(bias, expected) {
+		t.Fatalf("expected %v, got %v", expected, bias)
+	}
+
+	if _, err = tokenizer.NewLogitBias(enc).Add("hello", 101).Build(); err == nil {
+		t.Fatal("expected an error for an out of range bias")
+	}
+	if _, err = tokenizer.NewLogitBias(enc).Add("", 1).Build(); err == nil {
+		t.Fatal("expected an error for empty text")
+	}
+}
+
+func TestLogitBiasForModel(t *testing.T) {
+	if _, err := tokenizer.NewLogitBiasForModel("davinci"); err == nil {
+		t.Fatal("expected an error for an unknown model")
+	}
+
+	for _, test := range []struct {
+		model    string
+		expected map[string]int64
+	}{
+		// The tokens of "tiktoken is great!", then of "Hello" and " Hello",
+		// in cl100k_base and o200k_base.
+		{"gpt-4", map[string]int64{"83": -100, "1609": -100, "5963": -100, "374": -100, "2294": -100, "0": -100, "9906": 10, "22691": 10}},
+		{"gpt-4o", map[string]int64{"83": -100, "8251": -100, "2488": -100, "382": -100, "2212": -100, "0": -100, "13225": 10, "32949": 10}},
+	} {
+		builder, err := tokenizer.NewLogitBiasForModel(test.model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bias, err := builder.AddExact("tiktoken is great!", -100).Add("Hello", 10).Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(bias, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.model, test.expected, bias)
+		}
+	}
+}
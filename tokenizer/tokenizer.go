@@ -0,0 +1,417 @@
+// Package tokenizer implements the byte pair encodings used by OpenAI
+// models, so that tokens can be counted locally, without a request.
+//
+// The cl100k_base encoding is used by gpt-4, gpt-3.5-turbo and the
+// text-embedding models, and the o200k_base encoding is used by gpt-4o,
+// gpt-4.1 and the o-series reasoning models.
+//
+//	enc, err := tokenizer.ForModel("gpt-4o")
+//	if err != nil {
+//		return err
+//	}
+//	n := enc.Count("Hello, world!")
+//
+// The vocabularies of both encodings are embedded in the package,
+// compressed, from the data directory.
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The names of the supported encodings.
+const (
+	Cl100kBase = "cl100k_base"
+	O200kBase  = "o200k_base"
+)
+
+// Special tokens.
+const (
+	EndOfText   = "<|endoftext|>"
+	FimPrefix   = "<|fim_prefix|>"
+	FimMiddle   = "<|fim_middle|>"
+	FimSuffix   = "<|fim_suffix|>"
+	EndOfPrompt = "<|endofprompt|>"
+)
+
+// Returned by Get when the vocabulary of a supported encoding
+// has not been embedded in the package.
+var ErrVocabularyMissing = errors.New("tokenizer vocabulary is not embedded; see tokenizer/data/README.md")
+
+// The vocabularies are committed compressed, to keep the module small.
+//go:generate sh -c "curl -sSfL https://openaipublic.blob.core.windows.net/encodings/cl100k_base.tiktoken | gzip -9n > data/cl100k_base.tiktoken.gz"
+//go:generate sh -c "curl -sSfL https://openaipublic.blob.core.windows.net/encodings/o200k_base.tiktoken | gzip -9n > data/o200k_base.tiktoken.gz"
+
+//go:embed data
+var data embed.FS
+
+// The pre-tokenization patterns of the built in encodings, which split
+// text into the pieces which are encoded independently. The original patterns end with \s+(?!\S)|\s+,
+// but Go's regexp package does not support lookahead, so the final
+// alternative is emulated by Encoding.Split. \s is spelled out, since in
+// Go's regexp package it only matches ASCII whitespace.
+const (
+	ws       = `\t\n\v\f\r \x{85}\x{A0}\x{1680}\x{2000}-\x{200A}\x{2028}\x{2029}\x{202F}\x{205F}\x{3000}`
+	suffixes = `(?i:'s|'t|'re|'ve|'m|'ll|'d)`
+
+	// The pattern of the cl100k_base encoding.
+	Cl100kPattern = suffixes +
+		`|[^\r\n\p{L}\p{N}]?\p{L}+` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + ws + `\p{L}\p{N}]+[\r\n]*` +
+		`|[` + ws + `]*[\r\n]+` +
+		`|[` + ws + `]+`
+
+	// The pattern of the o200k_base encoding.
+	O200kPattern = `[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+` + suffixes + `?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*` + suffixes + `?` +
+		`|\p{N}{1,3}` +
+		`| ?[^` + ws + `\p{L}\p{N}]+[\r\n/]*` +
+		`|[` + ws + `]*[\r\n]+` +
+		`|[` + ws + `]+`
+)
+
+type encodingSpec struct {
+	pattern string
+	special map[string]int
+}
+
+var specs = map[string]encodingSpec{
+	Cl100kBase: {
+		pattern: Cl100kPattern,
+		special: map[string]int{
+			EndOfText:   100257,
+			FimPrefix:   100258,
+			FimMiddle:   100259,
+			FimSuffix:   100260,
+			EndOfPrompt: 100276,
+		},
+	},
+	O200kBase: {
+		pattern: O200kPattern,
+		special: map[string]int{
+			EndOfText:   199999,
+			EndOfPrompt: 200018,
+		},
+	},
+}
+
+// Maps model name prefixes to encodings. Longer prefixes take precedence.
+var modelPrefixes = map[string]string{
+	"gpt-4o":                 O200kBase,
+	"gpt-4.1":                O200kBase,
+	"gpt-4.5":                O200kBase,
+	"gpt-5":                  O200kBase,
+	"chatgpt-4o":             O200kBase,
+	"o1":                     O200kBase,
+	"o3":                     O200kBase,
+	"o4":                     O200kBase,
+	"gpt-4":                  Cl100kBase,
+	"gpt-3.5-turbo":          Cl100kBase,
+	"gpt-35-turbo":           Cl100kBase,
+	"text-embedding-ada-002": Cl100kBase,
+	"text-embedding-3":       Cl100kBase,
+	"ft:gpt-4o":              O200kBase,
+	"ft:gpt-4":               Cl100kBase,
+	"ft:gpt-3.5-turbo":       Cl100kBase,
+}
+
+// An Encoding converts between text and tokens. An Encoding is safe
+// for concurrent use.
+type Encoding struct {
+	name           string
+	ranks          map[string]int
+	decoder        map[int]string
+	special        map[string]int
+	specialDecoder map[int]string
+	pattern        *regexp.Regexp
+	specialPattern *regexp.Regexp
+}
+
+var (
+	encodings      = map[string]*Encoding{}
+	encodingsMutex sync.Mutex
+)
+
+// Get returns the named encoding, which must be one of
+// Cl100kBase or O200kBase. The vocabulary is loaded on first use.
+func Get(name string) (*Encoding, error) {
+	encodingsMutex.Lock()
+	defer encodingsMutex.Unlock()
+	if enc, ok := encodings[name]; ok {
+		return enc, nil
+	}
+
+	spec, ok := specs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	f, err := data.Open("data/" + name + ".tiktoken.gz")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrVocabularyMissing
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	vocabulary, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s vocabulary: %w", name, err)
+	}
+
+	enc, err := NewEncoding(name, vocabulary, spec.pattern, spec.special)
+	if err != nil {
+		return nil, err
+	}
+	encodings[name] = enc
+	return enc, nil
+}
+
+// ForModel returns the encoding used by the given model.
+func ForModel(model string) (*Encoding, error) {
+	name, err := EncodingNameForModel(model)
+	if err != nil {
+		return nil, err
+	}
+	return Get(name)
+}
+
+// EncodingNameForModel returns the name of the encoding used by the given model.
+func EncodingNameForModel(model string) (string, error) {
+	prefixes := make([]string, 0, len(modelPrefixes))
+	for prefix := range modelPrefixes {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(model, prefix) {
+			return modelPrefixes[prefix], nil
+		}
+	}
+	return "", fmt.Errorf("no known encoding for model %q", model)
+}
+
+// NewEncoding creates an Encoding from a vocabulary in the tiktoken
+// format, in which each line contains a base64 encoded token and its
+// rank, separated by a space. The text is split into pieces with the
+// given pattern before being encoded, and special maps special tokens
+// to their ranks. This permits the use of encodings not built into
+// the package. The vocabulary must contain a token for every byte.
+func NewEncoding(name string, vocabulary io.Reader, pattern string, special map[string]int) (*Encoding, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	enc := &Encoding{
+		name:           name,
+		ranks:          map[string]int{},
+		decoder:        map[int]string{},
+		special:        map[string]int{},
+		specialDecoder: map[int]string{},
+		pattern:        re,
+	}
+
+	scanner := bufio.NewScanner(vocabulary)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		token, rank, ok := bytes.Cut(text, []byte(" "))
+		if !ok {
+			return nil, fmt.Errorf("invalid vocabulary line %d", line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(string(token))
+		if err != nil {
+			return nil, fmt.Errorf("invalid token on vocabulary line %d: %w", line, err)
+		}
+		r, err := strconv.Atoi(string(rank))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rank on vocabulary line %d: %w", line, err)
+		}
+		enc.ranks[string(decoded)] = r
+		enc.decoder[r] = string(decoded)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(enc.ranks) == 0 {
+		return nil, errors.New("empty vocabulary")
+	}
+
+	quoted := make([]string, 0, len(special))
+	for token, rank := range special {
+		enc.special[token] = rank
+		enc.specialDecoder[rank] = token
+		quoted = append(quoted, regexp.QuoteMeta(token))
+	}
+	if len(quoted) != 0 {
+		sort.Strings(quoted)
+		enc.specialPattern = regexp.MustCompile(strings.Join(quoted, "|"))
+	}
+	return enc, nil
+}
+
+// Name returns the name of the encoding.
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// Encode converts text to tokens. Special tokens in text are
+// encoded as ordinary text.
+func (e *Encoding) Encode(text string) []int {
+	tokens := []int{}
+	for _, piece := range e.Split(text) {
+		tokens = e.encodePiece(tokens, piece)
+	}
+	return tokens
+}
+
+// EncodeWithSpecial converts text to tokens, encoding any
+// special tokens in text, such as EndOfText, as such.
+func (e *Encoding) EncodeWithSpecial(text string) []int {
+	if e.specialPattern == nil {
+		return e.Encode(text)
+	}
+	tokens := []int{}
+	start := 0
+	for _, loc := range e.specialPattern.FindAllStringIndex(text, -1) {
+		for _, piece := range e.Split(text[start:loc[0]]) {
+			tokens = e.encodePiece(tokens, piece)
+		}
+		tokens = append(tokens, e.special[text[loc[0]:loc[1]]])
+		start = loc[1]
+	}
+	for _, piece := range e.Split(text[start:]) {
+		tokens = e.encodePiece(tokens, piece)
+	}
+	return tokens
+}
+
+// Count returns the number of tokens in text, as encoded by Encode.
+func (e *Encoding) Count(text string) int {
+	return len(e.Encode(text))
+}
+
+// Decode converts tokens to text. Unknown tokens are skipped. Since a
+// single character may span several tokens, decoding a partial sequence
+// may produce invalid UTF-8.
+func (e *Encoding) Decode(tokens []int) string {
+	text := strings.Builder{}
+	for _, token := range tokens {
+		if piece, ok := e.decoder[token]; ok {
+			text.WriteString(piece)
+		} else if piece, ok = e.specialDecoder[token]; ok {
+			text.WriteString(piece)
+		}
+	}
+	return text.String()
+}
+
+// Token returns the token of the given text, which must be
+// encoded as a single token or be a special token.
+func (e *Encoding) Token(text string) (int, bool) {
+	if rank, ok := e.ranks[text]; ok {
+		return rank, true
+	}
+	rank, ok := e.special[text]
+	return rank, ok
+}
+
+// Split divides text into the pieces which are encoded independently,
+// according to the encoding's pattern.
+func (e *Encoding) Split(text string) []string {
+	pieces := []string{}
+	for len(text) != 0 {
+		loc := e.pattern.FindStringIndex(text)
+		if loc == nil {
+			break
+		}
+		if loc[0] != 0 {
+			// Unreachable with the built in patterns, which match
+			// every character, but possible with custom patterns.
+			text = text[loc[0]:]
+			loc[1] -= loc[0]
+		}
+		end := loc[1]
+		piece := text[:end]
+
+		// Emulate \s+(?!\S): a run of whitespace followed by a
+		// non-whitespace character leaves its last character to be
+		// matched along with that non-whitespace character.
+		if end < len(text) && isWhitespaceRun(piece) {
+			next, _ := utf8.DecodeRuneInString(text[end:])
+			_, size := utf8.DecodeLastRuneInString(piece)
+			if !unicode.IsSpace(next) && size < len(piece) {
+				end -= size
+				piece = text[:end]
+			}
+		}
+
+		pieces = append(pieces, piece)
+		text = text[end:]
+	}
+	return pieces
+}
+
+// isWhitespaceRun reports whether piece was matched by the final \s+
+// alternative of the pattern. A run containing a line break would have
+// been matched by the preceding \s*[\r\n]+ alternative instead.
+func isWhitespaceRun(piece string) bool {
+	for _, r := range piece {
+		if !unicode.IsSpace(r) || r == '\r' || r == '\n' {
+			return false
+		}
+	}
+	return true
+}
+
+// encodePiece appends the tokens of piece to tokens, by repeatedly merging
+// the adjacent pair of parts with the lowest rank, starting from bytes.
+func (e *Encoding) encodePiece(tokens []int, piece string) []int {
+	if rank, ok := e.ranks[piece]; ok {
+		return append(tokens, rank)
+	}
+
+	// The start offset of each part in piece.
+	parts := make([]int, len(piece)+1)
+	for i := range parts {
+		parts[i] = i
+	}
+	for len(parts) > 2 {
+		minRank, minIndex := -1, -1
+		for i := 0; i+2 < len(parts); i++ {
+			rank, ok := e.ranks[piece[parts[i]:parts[i+2]]]
+			if ok && (minRank < 0 || rank < minRank) {
+				minRank, minIndex = rank, i
+			}
+		}
+		if minIndex < 0 {
+			break
+		}
+		parts = append(parts[:minIndex+1], parts[minIndex+2:]...)
+	}
+
+	for i := 0; i+1 < len(parts); i++ {
+		if rank, ok := e.ranks[piece[parts[i]:parts[i+1]]]; ok {
+			tokens = append(tokens, rank)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,149 @@
+package tokenizer_test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Kardbord/gopenai/tokenizer"
+)
+
+// A vocabulary containing every byte, and a few merges.
+func testVocabulary() string {
+	vocab := strings.Builder{}
+	rank := 0
+	add := func(token string) {
+		fmt.Fprintf(&vocab, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+		rank++
+	}
+	for b := 0; b < 256; b++ {
+		add(string([]byte{byte(b)}))
+	}
+	for _, token := range []string{"he", "ll", "hell", " w", "or", " wor", "ld", " world"} {
+		add(token)
+	}
+	return vocab.String()
+}
+
+func newTestEncoding(t *testing.T) *tokenizer.Encoding {
+	enc, err := tokenizer.NewEncoding("test", strings.NewReader(testVocabulary()), tokenizer.Cl100kPattern, map[string]int{
+		tokenizer.EndOfText: 1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc
+}
+
+func TestSplit(t *testing.T) {
+	enc := newTestEncoding(t)
+	pieces := enc.Split("Hello  world\n\nfoo's 12345 !?\tx  \ty")
+	expected := []string{"Hello", " ", " world", "\n\n", "foo", "'s", " ", "123", "45", " !?", "\tx", "  ", "\ty"}
+	if !reflect.DeepEqual(pieces, expected) {
+		t.Fatalf("expected %q, got %q", expected, pieces)
+	}
+
+	o200k, err := tokenizer.NewEncoding("test", strings.NewReader(testVocabulary()), tokenizer.O200kPattern, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pieces = o200k.Split("HelloWorld don't")
+	expected = []string{"Hello", "World", " don't"}
+	if !reflect.DeepEqual(pieces, expected) {
+		t.Fatalf("expected %q, got %q", expected, pieces)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	enc := newTestEncoding(t)
+
+	tokens := enc.Encode("hello world")
+	expected := []int{258, 'o', 263}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Fatalf("expected %v, got %v", expected, tokens)
+	}
+	if text := enc.Decode(tokens); text != "hello world" {
+		t.Fatalf("unexpected decoded text %q", text)
+	}
+
+	if n := enc.Count("hello" + tokenizer.EndOfText); n != 2+len(tokenizer.EndOfText) {
+		t.Fatalf("expected special tokens to be encoded as text, got %d tokens", n)
+	}
+	tokens = enc.EncodeWithSpecial("hello" + tokenizer.EndOfText)
+	expected = []int{258, 'o', 1000}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Fatalf("expected %v, got %v", expected, tokens)
+	}
+	if text := enc.Decode(tokens); text != "hello"+tokenizer.EndOfText {
+		t.Fatalf("unexpected decoded text %q", text)
+	}
+
+	text := "héllo, 世界! 🙂"
+	if decoded := enc.Decode(enc.Encode(text)); decoded != text {
+		t.Fatalf("expected %q, got %q", text, decoded)
+	}
+}
+
+func TestEncodingForModel(t *testing.T) {
+	for model, expected := range map[string]string{
+		"gpt-4o-mini":            tokenizer.O200kBase,
+		"gpt-4-turbo":            tokenizer.Cl100kBase,
+		"gpt-3.5-turbo-0125":     tokenizer.Cl100kBase,
+		"o3-mini":                tokenizer.O200kBase,
+		"text-embedding-3-small": tokenizer.Cl100kBase,
+	} {
+		name, err := tokenizer.EncodingNameForModel(model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != expected {
+			t.Fatalf("expected %s for %s, got %s", expected, model, name)
+		}
+	}
+	if _, err := tokenizer.EncodingNameForModel("davinci"); err == nil {
+		t.Fatal("expected an error for an unknown model")
+	}
+}
+
+func TestBuiltInEncodings(t *testing.T) {
+	// The tokens produced by tiktoken.
+	for _, test := range []struct {
+		encoding string
+		text     string
+		tokens   []int
+	}{
+		{tokenizer.Cl100kBase, "hello world", []int{15339, 1917}},
+		{tokenizer.Cl100kBase, "tiktoken is great!", []int{83, 1609, 5963, 374, 2294, 0}},
+		{tokenizer.Cl100kBase, "I'm can't 12345 6789   spaces\n\n\tTabs", []int{40, 2846, 649, 956, 220, 4513, 1774, 220, 17458, 24, 256, 12908, 271, 10473, 3518}},
+		{tokenizer.Cl100kBase, "héllo wörld 日本語 🤖🦀", []int{71, 19010, 385, 289, 9603, 509, 76502, 22656, 45918, 252, 11410, 97, 244, 9468, 99, 222}},
+		{tokenizer.O200kBase, "hello world", []int{24912, 2375}},
+		{tokenizer.O200kBase, "tiktoken is great!", []int{83, 8251, 2488, 382, 2212, 0}},
+		{tokenizer.O200kBase, "I'm can't 12345 6789   spaces\n\n\tTabs", []int{15390, 8535, 220, 7633, 2548, 220, 30833, 24, 256, 18608, 279, 19767, 6071}},
+		{tokenizer.O200kBase, "héllo wörld 日本語 🤖🦀", []int{79163, 72807, 286, 2877, 582, 17428, 40909, 93643, 244, 4103, 99, 222}},
+	} {
+		enc, err := tokenizer.Get(test.encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tokens := enc.Encode(test.text); !reflect.DeepEqual(tokens, test.tokens) {
+			t.Errorf("%s: expected %v for %q, got %v", test.encoding, test.tokens, test.text, tokens)
+		}
+		if n := enc.Count(test.text); n != len(test.tokens) {
+			t.Errorf("%s: expected %d tokens for %q, got %d", test.encoding, len(test.tokens), test.text, n)
+		}
+		if text := enc.Decode(test.tokens); text != test.text {
+			t.Errorf("%s: expected %q, got %q", test.encoding, test.text, text)
+		}
+	}
+
+	enc, err := tokenizer.Get(tokenizer.O200kBase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := "The quick brown fox jumps over the lazy dog."
+	if decoded := enc.Decode(enc.Encode(text)); decoded != text {
+		t.Fatalf("expected %q, got %q", text, decoded)
+	}
+}